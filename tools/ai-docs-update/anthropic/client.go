@@ -0,0 +1,381 @@
+// Package anthropic provides an llm.Provider backed by the Anthropic Messages API.
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/llm"
+)
+
+// Default configuration values.
+const (
+	DefaultModel      = "claude-sonnet-4-5"
+	DefaultAPIBase    = "https://api.anthropic.com"
+	DefaultMaxTokens  = 8192
+	DefaultMaxRetries = 3
+	RequestTimeout    = 2 * time.Minute
+
+	// APIVersion is the value of the anthropic-version header.
+	APIVersion = "2023-06-01"
+)
+
+// ProviderName is the llm.Provider name of this client.
+const ProviderName = "anthropic"
+
+// Environment variable names.
+const (
+	EnvAnthropicAPIKey  = "ANTHROPIC_API_KEY"
+	EnvAnthropicModel   = "ANTHROPIC_MODEL"
+	EnvAnthropicAPIBase = "ANTHROPIC_API_BASE"
+)
+
+// retryBaseDelay is the initial backoff delay between retries.
+const retryBaseDelay = time.Second
+
+// Client is an Anthropic Messages API client.
+// It implements llm.Provider.
+type Client struct {
+	llm.UsageTracker
+
+	httpClient  *http.Client
+	apiKey      string
+	model       string
+	apiBase     string
+	temperature float64
+	maxTokens   int
+	maxRetries  int
+	transport   http.RoundTripper
+}
+
+var _ llm.Provider = (*Client)(nil)
+
+// ClientOption is a function that configures a Client.
+type ClientOption func(*Client)
+
+// WithModel sets the model to use.
+func WithModel(model string) ClientOption {
+	return func(c *Client) {
+		c.model = model
+	}
+}
+
+// WithAPIBase sets the API base URL (without the /v1 suffix).
+func WithAPIBase(apiBase string) ClientOption {
+	return func(c *Client) {
+		c.apiBase = apiBase
+	}
+}
+
+// WithTemperature sets the temperature parameter.
+func WithTemperature(temp float64) ClientOption {
+	return func(c *Client) {
+		c.temperature = temp
+	}
+}
+
+// WithMaxTokens sets the maximum tokens for responses.
+func WithMaxTokens(tokens int) ClientOption {
+	return func(c *Client) {
+		c.maxTokens = tokens
+	}
+}
+
+// WithMaxRetries sets the maximum number of retries.
+func WithMaxRetries(retries int) ClientOption {
+	return func(c *Client) {
+		c.maxRetries = retries
+	}
+}
+
+// WithHTTPTransport sets the HTTP transport used for API calls.
+func WithHTTPTransport(transport http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.transport = transport
+	}
+}
+
+// NewClient creates a new Anthropic API client.
+// It reads configuration from environment variables with sensible defaults.
+func NewClient(apiKey string, opts ...ClientOption) *Client {
+	model := os.Getenv(EnvAnthropicModel)
+	if model == "" {
+		model = DefaultModel
+	}
+
+	apiBase := os.Getenv(EnvAnthropicAPIBase)
+	if apiBase == "" {
+		apiBase = DefaultAPIBase
+	}
+
+	c := &Client{
+		apiKey:      apiKey,
+		model:       model,
+		apiBase:     apiBase,
+		temperature: 0, // Deterministic output
+		maxTokens:   DefaultMaxTokens,
+		maxRetries:  DefaultMaxRetries,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.httpClient = &http.Client{Timeout: RequestTimeout, Transport: c.transport}
+	return c
+}
+
+// messagesRequest is the Messages API request body.
+type messagesRequest struct {
+	Model       string    `json:"model"`
+	System      string    `json:"system,omitempty"`
+	Messages    []message `json:"messages"`
+	MaxTokens   int       `json:"max_tokens"`
+	Temperature float64   `json:"temperature"`
+}
+
+// message is a single Messages API message.
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// messagesResponse is the Messages API response body.
+type messagesResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// errorResponse is the Messages API error body.
+type errorResponse struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// APIError is returned for non-2xx responses.
+type APIError struct {
+	StatusCode int
+	Type       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("anthropic api error: %d %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+// ChatCompletion implements llm.Provider.
+func (c *Client) ChatCompletion(ctx context.Context, req llm.Request) (string, error) {
+	system, messages := toAPIMessages(req.Messages)
+	if len(messages) == 0 {
+		return "", errors.New("no user messages in request")
+	}
+
+	body, err := json.Marshal(messagesRequest{
+		Model:       c.model,
+		System:      system,
+		Messages:    messages,
+		MaxTokens:   c.maxTokens,
+		Temperature: c.temperature,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	log.Printf("Calling Anthropic API (model: %s)", c.model)
+	resp, err := c.doWithRetry(ctx, body)
+	if err != nil {
+		return "", err
+	}
+
+	c.Record(resp.Usage.InputTokens, resp.Usage.OutputTokens)
+
+	var text strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", errors.New("no text content in response")
+	}
+	if resp.StopReason == "max_tokens" {
+		log.Printf("WARNING: Anthropic response truncated at max_tokens (%d)", c.maxTokens)
+	}
+
+	return text.String(), nil
+}
+
+// doWithRetry sends the request, retrying rate limits and server errors
+// with exponential backoff (honoring retry-after when present).
+func (c *Client) doWithRetry(ctx context.Context, body []byte) (*messagesResponse, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := retryDelay(lastErr, attempt)
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("anthropic api error: %w (last error: %v)", ctx.Err(), lastErr)
+			case <-time.After(delay):
+			}
+		}
+
+		resp, err := c.do(ctx, body)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+		if !isRetryable(err) || ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// do sends a single request.
+func (c *Client) do(ctx context.Context, body []byte) (*messagesResponse, error) {
+	url := strings.TrimRight(c.apiBase, "/") + "/v1/messages"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", c.apiKey)
+	httpReq.Header.Set("anthropic-version", APIVersion)
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, &retryableError{err: fmt.Errorf("anthropic api error: %w", err)}
+	}
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, &retryableError{err: fmt.Errorf("failed to read response: %w", err)}
+	}
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: httpResp.StatusCode, Message: strings.TrimSpace(string(data))}
+		var errResp errorResponse
+		if json.Unmarshal(data, &errResp) == nil && errResp.Error.Message != "" {
+			apiErr.Type = errResp.Error.Type
+			apiErr.Message = errResp.Error.Message
+		}
+		if isRetryableStatus(httpResp.StatusCode) {
+			return nil, &retryableError{err: apiErr, retryAfter: parseRetryAfter(httpResp.Header.Get("retry-after"))}
+		}
+		return nil, apiErr
+	}
+
+	var resp messagesResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &resp, nil
+}
+
+// retryableError marks errors that may succeed on retry.
+type retryableError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// isRetryable reports whether err is a retryableError.
+func isRetryable(err error) bool {
+	var re *retryableError
+	return errors.As(err, &re)
+}
+
+// isRetryableStatus reports whether an HTTP status should be retried.
+// 529 is Anthropic's "overloaded" status.
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == 529 || status >= 500
+}
+
+// retryDelay returns the backoff delay before the given attempt.
+func retryDelay(lastErr error, attempt int) time.Duration {
+	var re *retryableError
+	if errors.As(lastErr, &re) && re.retryAfter > 0 {
+		return re.retryAfter
+	}
+	return retryBaseDelay << (attempt - 1)
+}
+
+// parseRetryAfter parses a retry-after header in seconds.
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// toAPIMessages maps chat messages to the Messages API shape.
+// System messages become the top-level system prompt; consecutive messages
+// with the same role are merged because the API requires alternating roles.
+func toAPIMessages(messages []llm.ChatMessage) (string, []message) {
+	var system []string
+	var result []message
+	for _, m := range messages {
+		role := m.Role
+		switch role {
+		case llm.RoleSystem:
+			system = append(system, m.Content)
+			continue
+		case llm.RoleUser, llm.RoleAssistant:
+		default:
+			log.Printf("WARNING: unknown message role %q, treating as user message", m.Role)
+			role = llm.RoleUser
+		}
+
+		if n := len(result); n > 0 && result[n-1].Role == role {
+			result[n-1].Content += "\n\n" + m.Content
+			continue
+		}
+		result = append(result, message{Role: role, Content: m.Content})
+	}
+	return strings.Join(system, "\n\n"), result
+}
+
+// SupportsJSONMode implements llm.Provider.
+// The Messages API has no JSON response format, so Request.JSONResponse is
+// ignored and JSON output relies on llm.JSONOnlyInstruction in the prompt.
+func (c *Client) SupportsJSONMode() bool {
+	return false
+}
+
+// Name implements llm.Provider.
+func (c *Client) Name() string {
+	return ProviderName
+}
+
+// Model implements llm.Provider.
+func (c *Client) Model() string {
+	return c.model
+}
+
+// GetAPIBase returns the API base URL being used.
+func (c *Client) GetAPIBase() string {
+	return c.apiBase
+}
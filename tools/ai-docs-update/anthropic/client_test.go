@@ -0,0 +1,231 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/llm"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/openai"
+)
+
+// fakeAPI is an httptest stand-in for the Messages API. It answers each
+// request with the next scripted reply (the last one repeats) and records
+// the requests it received.
+type fakeAPI struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	replies  []func(w http.ResponseWriter)
+	requests []*http.Request
+	bodies   []messagesRequest
+}
+
+func newFakeAPI(t *testing.T, replies ...func(w http.ResponseWriter)) *fakeAPI {
+	t.Helper()
+	f := &fakeAPI{replies: replies}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/messages" {
+			http.Error(w, "unexpected request "+r.Method+" "+r.URL.Path, http.StatusNotFound)
+			return
+		}
+		var body messagesRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.requests = append(f.requests, r)
+		f.bodies = append(f.bodies, body)
+		reply := f.replies[min(len(f.requests), len(f.replies))-1]
+		f.mu.Unlock()
+		reply(w)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// count returns the number of requests received.
+func (f *fakeAPI) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.requests)
+}
+
+// textReply answers with a single text block.
+func textReply(text, stopReason string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"content": [{"type": "text", "text": %q}], "stop_reason": %q, "usage": {"input_tokens": 120, "output_tokens": 30}}`,
+			text, stopReason)
+	}
+}
+
+// errorReply answers with an API error status and body.
+func errorReply(status int, errType, message string, retryAfter string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		if retryAfter != "" {
+			w.Header().Set("retry-after", retryAfter)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"type": "error", "error": {"type": %q, "message": %q}}`, errType, message)
+	}
+}
+
+func TestChatCompletionRequest(t *testing.T) {
+	api := newFakeAPI(t, textReply("Hello", "end_turn"))
+	c := NewClient("test-key", WithAPIBase(api.URL), WithModel("claude-test"), WithMaxTokens(512))
+
+	got, err := c.ChatCompletion(context.Background(), llm.Request{Messages: []llm.ChatMessage{
+		{Role: llm.RoleSystem, Content: "You are a documentation analyst."},
+		{Role: llm.RoleSystem, Content: "Respond briefly."},
+		{Role: llm.RoleUser, Content: "Issue: flag triggers"},
+		{Role: llm.RoleUser, Content: "Docs: a.md"},
+		{Role: llm.RoleAssistant, Content: "Which one?"},
+		{Role: "tool", Content: "a.md"},
+	}})
+	if err != nil {
+		t.Fatalf("ChatCompletion() error = %v", err)
+	}
+	if got != "Hello" {
+		t.Errorf("ChatCompletion() = %q, want %q", got, "Hello")
+	}
+
+	req, body := api.requests[0], api.bodies[0]
+	if h := req.Header.Get("x-api-key"); h != "test-key" {
+		t.Errorf("x-api-key = %q, want %q", h, "test-key")
+	}
+	if h := req.Header.Get("anthropic-version"); h != APIVersion {
+		t.Errorf("anthropic-version = %q, want %q", h, APIVersion)
+	}
+	if body.Model != "claude-test" || body.MaxTokens != 512 || body.Temperature != 0 {
+		t.Errorf("model, max_tokens, temperature = %q, %d, %v", body.Model, body.MaxTokens, body.Temperature)
+	}
+	if want := "You are a documentation analyst.\n\nRespond briefly."; body.System != want {
+		t.Errorf("system = %q, want %q", body.System, want)
+	}
+	want := []message{
+		{Role: llm.RoleUser, Content: "Issue: flag triggers\n\nDocs: a.md"},
+		{Role: llm.RoleAssistant, Content: "Which one?"},
+		{Role: llm.RoleUser, Content: "a.md"}, // unknown roles are sent as user turns
+	}
+	if fmt.Sprint(body.Messages) != fmt.Sprint(want) {
+		t.Errorf("messages = %+v, want %+v", body.Messages, want)
+	}
+
+	if usage := c.Usage(); usage.Requests != 1 || usage.PromptTokens != 120 || usage.CompletionTokens != 30 {
+		t.Errorf("Usage() = %+v, want 1 request, 120 prompt and 30 completion tokens", usage)
+	}
+}
+
+func TestChatCompletionResponses(t *testing.T) {
+	tests := []struct {
+		name         string
+		replies      []func(w http.ResponseWriter)
+		want         string
+		wantRequests int
+		wantErr      *APIError // nil for success
+	}{
+		{
+			name:         "overloaded then success",
+			replies:      []func(w http.ResponseWriter){errorReply(529, "overloaded_error", "Overloaded", "1"), textReply("ok", "end_turn")},
+			want:         "ok",
+			wantRequests: 2,
+		},
+		{
+			name:         "rate limit then success",
+			replies:      []func(w http.ResponseWriter){errorReply(http.StatusTooManyRequests, "rate_limit_error", "Slow down", "1"), textReply("ok", "end_turn")},
+			want:         "ok",
+			wantRequests: 2,
+		},
+		{
+			name:         "truncated at max_tokens",
+			replies:      []func(w http.ResponseWriter){textReply("<updated_document>\npartial", "max_tokens")},
+			want:         "<updated_document>\npartial",
+			wantRequests: 1,
+		},
+		{
+			name:         "bad request is not retried",
+			replies:      []func(w http.ResponseWriter){errorReply(http.StatusBadRequest, "invalid_request_error", "max_tokens: too large", "")},
+			wantRequests: 1,
+			wantErr:      &APIError{StatusCode: http.StatusBadRequest, Type: "invalid_request_error", Message: "max_tokens: too large"},
+		},
+		{
+			name:         "server errors exhaust the retries",
+			replies:      []func(w http.ResponseWriter){errorReply(http.StatusInternalServerError, "api_error", "Internal error", "1")},
+			wantRequests: 2,
+			wantErr:      &APIError{StatusCode: http.StatusInternalServerError, Type: "api_error", Message: "Internal error"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newFakeAPI(t, tt.replies...)
+			c := NewClient("test-key", WithAPIBase(api.URL), WithMaxRetries(1))
+
+			got, err := c.ChatCompletion(context.Background(), llm.Request{Messages: []llm.ChatMessage{{Role: llm.RoleUser, Content: "Hi"}}})
+			if tt.wantErr != nil {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || *apiErr != *tt.wantErr {
+					t.Fatalf("ChatCompletion() error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil || got != tt.want {
+				t.Fatalf("ChatCompletion() = %q, %v; want %q", got, err, tt.want)
+			}
+			if n := api.count(); n != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", n, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		attempt int
+		want    time.Duration
+	}{
+		{name: "retry-after", err: &retryableError{err: errors.New("x"), retryAfter: 7 * time.Second}, attempt: 1, want: 7 * time.Second},
+		{name: "first backoff", err: &retryableError{err: errors.New("x")}, attempt: 1, want: retryBaseDelay},
+		{name: "exponential backoff", err: &retryableError{err: errors.New("x")}, attempt: 3, want: 4 * retryBaseDelay},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.err, tt.attempt); got != tt.want {
+			t.Errorf("%s: retryDelay() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	for value, want := range map[string]time.Duration{"3": 3 * time.Second, " 1 ": time.Second, "0": 0, "-1": 0, "": 0, "Wed, 21 Oct 2015 07:28:00 GMT": 0} {
+		if got := parseRetryAfter(value); got != want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestIdentifyPromptOnlyJSON(t *testing.T) {
+	reply := "```json\n" + `{"needs_update": true, "reason": "New trigger type", "files_to_update": [{"path": "feature-flags/triggers.mdx", "update_type": "add_inline", "brief_description": "Mention webhooks", "target_location": "## Triggers"}]}` + "\n```"
+	api := newFakeAPI(t, textReply(reply, "end_turn"))
+	c := NewClient("test-key", WithAPIBase(api.URL))
+
+	got, err := openai.IdentifyDocsToUpdate(context.Background(), c, openai.IdentifyRequest{
+		IssueTitle:   "Add webhook triggers",
+		DocsManifest: &openai.DocsManifest{Files: []openai.DocFile{{Path: "feature-flags/triggers.mdx", Title: "Triggers"}}},
+	})
+	if err != nil {
+		t.Fatalf("IdentifyDocsToUpdate() error = %v", err)
+	}
+	if !got.NeedsUpdate || len(got.FilesToUpdate) != 1 || got.FilesToUpdate[0].Path != "feature-flags/triggers.mdx" {
+		t.Errorf("IdentifyDocsToUpdate() = %+v", got)
+	}
+	if system := api.bodies[0].System; !strings.Contains(system, llm.JSONOnlyInstruction) {
+		t.Errorf("system prompt = %q, want the JSON-only instruction", system)
+	}
+}
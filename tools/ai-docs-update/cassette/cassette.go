@@ -0,0 +1,264 @@
+// Package cassette records and replays LLM API calls for offline, deterministic runs.
+//
+// In record mode every successful request/response pair is forwarded to the real
+// API and saved as a JSON fixture named after a hash of the prompt. In replay mode
+// the fixtures are served back without any network access.
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Mode selects how the Transport handles requests.
+type Mode string
+
+const (
+	// ModeOff passes requests through unchanged.
+	ModeOff Mode = ""
+	// ModeRecord forwards requests and saves successful responses as fixtures.
+	ModeRecord Mode = "record"
+	// ModeReplay serves responses from fixtures and never touches the network.
+	ModeReplay Mode = "replay"
+)
+
+// ErrInvalidMode indicates an unknown cassette mode.
+var ErrInvalidMode = errors.New("invalid cassette mode")
+
+// Interaction is a single recorded request/response pair.
+type Interaction struct {
+	Key      string          `json:"key"`
+	Request  RecordedRequest `json:"request"`
+	Response RecordedReply   `json:"response"`
+}
+
+// RecordedRequest is the request side of an Interaction.
+type RecordedRequest struct {
+	Method string          `json:"method"`
+	URL    string          `json:"url"`
+	Body   json.RawMessage `json:"body"`
+}
+
+// RecordedReply is the response side of an Interaction.
+type RecordedReply struct {
+	StatusCode int             `json:"status_code"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// Transport is an http.RoundTripper that records or replays interactions.
+type Transport struct {
+	mode Mode
+	dir  string
+	next http.RoundTripper
+	mu   sync.Mutex // serializes fixture writes
+}
+
+// ParseMode validates a mode string ("", "off", "record", "replay").
+func ParseMode(s string) (Mode, error) {
+	switch Mode(strings.ToLower(strings.TrimSpace(s))) {
+	case ModeOff, "off":
+		return ModeOff, nil
+	case ModeRecord:
+		return ModeRecord, nil
+	case ModeReplay:
+		return ModeReplay, nil
+	default:
+		return ModeOff, fmt.Errorf("%w: %q (use record or replay)", ErrInvalidMode, s)
+	}
+}
+
+// NewTransport creates a Transport storing fixtures in dir.
+// If next is nil, http.DefaultTransport is used for record mode.
+func NewTransport(mode Mode, dir string, next http.RoundTripper) (*Transport, error) {
+	if mode != ModeOff && dir == "" {
+		return nil, errors.New("cassette directory is required")
+	}
+	if mode == ModeRecord {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create cassette directory: %w", err)
+		}
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Transport{mode: mode, dir: dir, next: next}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.mode == ModeOff {
+		return t.next.RoundTrip(req)
+	}
+
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	key := Key(body)
+
+	if t.mode == ModeReplay {
+		return t.replay(req, key)
+	}
+	return t.record(req, key, body)
+}
+
+// replay serves a recorded response, or a 404 error response if none exists.
+// A 404 is used instead of a transport error so SDK clients do not retry.
+func (t *Transport) replay(req *http.Request, key string) (*http.Response, error) {
+	data, err := os.ReadFile(t.fixturePath(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			msg := fmt.Sprintf("cassette: no recorded interaction for key %s in %s", key, t.dir)
+			return newResponse(req, http.StatusNotFound, errorBody(msg)), nil
+		}
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	var in Interaction
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", key, err)
+	}
+
+	return newResponse(req, in.Response.StatusCode, decodeBody(in.Response.Body)), nil
+}
+
+// record forwards the request and saves the response if it succeeded.
+func (t *Transport) record(req *http.Request, key string, body []byte) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	// Only successful calls are useful as fixtures; errors and rate limits are retried
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		in := Interaction{
+			Key: key,
+			Request: RecordedRequest{
+				Method: req.Method,
+				URL:    req.URL.Path,
+				Body:   encodeBody(body),
+			},
+			Response: RecordedReply{
+				StatusCode: resp.StatusCode,
+				Body:       encodeBody(respBody),
+			},
+		}
+		if err := t.save(in); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// save writes an interaction to its fixture file.
+func (t *Transport) save(in Interaction) error {
+	data, err := json.MarshalIndent(in, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := os.WriteFile(t.fixturePath(in.Key), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// fixturePath returns the file path for a key.
+func (t *Transport) fixturePath(key string) string {
+	return filepath.Join(t.dir, key+".json")
+}
+
+// Key returns the fixture key for a request body.
+// Only the prompt ("system" and "messages" fields) is hashed, so changing the
+// model or sampling parameters does not invalidate existing fixtures.
+// Bodies that are not JSON are hashed as-is.
+func Key(body []byte) string {
+	var fields struct {
+		System   json.RawMessage `json:"system"`
+		Messages json.RawMessage `json:"messages"`
+	}
+	data := body
+	if err := json.Unmarshal(body, &fields); err == nil && len(fields.Messages) > 0 {
+		data = append(append([]byte{}, fields.System...), fields.Messages...)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// readRequestBody reads and restores the request body.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// encodeBody stores JSON bodies verbatim (readable fixtures) and others as JSON strings.
+func encodeBody(body []byte) json.RawMessage {
+	if json.Valid(body) {
+		return json.RawMessage(body)
+	}
+	encoded, _ := json.Marshal(string(body))
+	return encoded
+}
+
+// decodeBody reverses encodeBody.
+func decodeBody(raw json.RawMessage) []byte {
+	var s string
+	if len(raw) > 0 && raw[0] == '"' && json.Unmarshal(raw, &s) == nil {
+		return []byte(s)
+	}
+	return raw
+}
+
+// errorBody builds an OpenAI-style error payload.
+func errorBody(message string) []byte {
+	data, _ := json.Marshal(map[string]any{
+		"error": map[string]string{
+			"message": message,
+			"type":    "cassette_miss",
+		},
+	})
+	return data
+}
+
+// newResponse builds a JSON http.Response for req.
+func newResponse(req *http.Request, status int, body []byte) *http.Response {
+	return &http.Response{
+		StatusCode:    status,
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
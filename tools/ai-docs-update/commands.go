@@ -0,0 +1,567 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/cassette"
+	appconfig "github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/config"
+	appctx "github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/context"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/docs"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/guardrails"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/openai"
+)
+
+// command is a subcommand that runs a single step of the pipeline.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+// commands lists the subcommands. Without a subcommand the full pipeline runs.
+var commands = []command{
+	{"fetch", "Fetch issue and PR context from GitHub into files", runFetchCommand},
+	{"manifest", "Print the docs manifest as JSON, YAML or CSV", runManifestCommand},
+	{"identify", "Run Phase 1 only and print the identify response as JSON", runIdentifyCommand},
+	{"generate", "Run Phase 2 for a single document", runGenerateCommand},
+	{"validate", "Run the output guardrails on existing files", runValidateCommand},
+	{"explain", "Show how docs are classified and which rule applied", runExplainCommand},
+	{"links", "Print the internal link graph and broken links as JSON", runLinksCommand},
+	{"duplicates", "Report docs that repeat content from docs they link to", runDuplicatesCommand},
+	{"config", "Print the effective configuration (config print)", runConfigCommand},
+}
+
+// findCommand returns the subcommand with the given name, or nil.
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// usage prints help for the full run and lists the subcommands.
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "Usage: ai-docs-update [flags]")
+	fmt.Fprintln(out, "       ai-docs-update <command> [flags]")
+	fmt.Fprintln(out, "\nWithout a command, identifies and updates docs end to end.")
+	fmt.Fprintln(out, "\nCommands:")
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-9s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(out, "\nRun 'ai-docs-update <command> -h' for command flags.")
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+}
+
+// registerContextFlags registers the issue, PR and glossary input flags.
+func registerContextFlags(fs *flag.FlagSet, cfg *config) {
+	registerIssueNumberFlags(fs, cfg)
+	fs.StringVar(&cfg.issueTitleFile, "issue-title-file", "", "Path to issue title file")
+	fs.StringVar(&cfg.issueBodyFile, "issue-body-file", "", "Path to issue body file")
+	fs.StringVar(&cfg.prTitleFile, "pr-title-file", "", "Path to PR title file")
+	fs.StringVar(&cfg.prBodyFile, "pr-body-file", "", "Path to PR body file")
+	fs.StringVar(&cfg.diffFile, "diff-file", "", "Path to diff file")
+	fs.StringVar(&cfg.glossaryFile, "glossary-file", "", "Path to vocabulary.json file")
+}
+
+// registerIssueNumberFlags registers the flags selecting an issue and PRs to fetch from GitHub.
+func registerIssueNumberFlags(fs *flag.FlagSet, cfg *config) {
+	fs.IntVar(&cfg.issueNumber, "issue-number", 0, "Fetch context for this issue from GitHub instead of reading --issue-*-file/--pr-*-file")
+	fs.Func("pr-numbers", "Comma-separated PR numbers to fetch with --issue-number (default: none, see --discover-prs)", func(s string) error {
+		for _, item := range appconfig.SplitList(s) {
+			n, err := strconv.Atoi(strings.TrimPrefix(item, "#"))
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid PR number %q", item)
+			}
+			cfg.prNumbers = append(cfg.prNumbers, n)
+		}
+		return nil
+	})
+	fs.BoolVar(&cfg.discoverPRs, "discover-prs", false, "Without --pr-numbers, fetch the merged PRs that reference the issue")
+}
+
+// registerLLMFlags registers the cassette flags used by commands that call the LLM.
+func registerLLMFlags(fs *flag.FlagSet, cfg *config) {
+	fs.Func("cassette-mode", "Record or replay LLM API calls: record, replay (default: off)", func(s string) error {
+		mode, err := cassette.ParseMode(s)
+		cfg.cassetteMode = mode
+		return err
+	})
+	fs.StringVar(&cfg.cassetteDir, "cassette-dir", "", "Directory for recorded LLM interactions (required with --cassette-mode)")
+}
+
+// registerWriteFlags registers the flags controlling how generated docs are written.
+func registerWriteFlags(fs *flag.FlagSet, cfg *config) {
+	fs.BoolVar(&cfg.dryRun, "dry-run", false, "Print unified diffs instead of writing docs")
+	fs.StringVar(&cfg.patchFile, "patch-file", "", "Write dry-run diffs to this file instead of stdout (paths are relative to --docs-dir)")
+}
+
+// registerManifestFileFlag registers --manifest-file for commands that need the manifest.
+func registerManifestFileFlag(fs *flag.FlagSet, cfg *config) {
+	fs.StringVar(&cfg.manifestFile, "manifest-file", "", "Use a manifest written by the manifest command instead of scanning the docs directory")
+}
+
+// registerManifestCacheFlags registers the flags for the incremental manifest cache.
+func registerManifestCacheFlags(fs *flag.FlagSet, cfg *config) {
+	fs.StringVar(&cfg.manifestCache, "manifest-cache", "", "Cache parsed doc metadata in this file and re-parse only changed docs")
+	fs.BoolVar(&cfg.rebuild, "rebuild-manifest", false, "Ignore the existing --manifest-cache and re-parse all docs")
+}
+
+// parseCommand parses subcommand flags and merges the settings into cfg.
+func parseCommand(fs *flag.FlagSet, configFile *string, args []string, cfg *config) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	settings, err := loadSettings(*configFile, fs)
+	if err != nil {
+		return err
+	}
+	cfg.settings = settings
+	return nil
+}
+
+// writeJSON writes v as indented JSON to path, or to stdout if path is empty.
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal output: %w", err)
+	}
+	return writeOutput(path, append(data, '\n'))
+}
+
+// writeOutput writes data to path, or to stdout if path is empty.
+func writeOutput(path string, data []byte) error {
+	if path == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// Context file names written by the fetch command, matching the --*-file flags.
+const (
+	issueTitleFileName = "issue_title.txt"
+	issueBodyFileName  = "issue_body.txt"
+	prTitleFileName    = "pr_title.txt"
+	prBodyFileName     = "pr_body.txt"
+	diffFileName       = "diff.patch"
+	contextFileName    = "context.json"
+)
+
+// runFetchCommand fetches the issue and PR context from GitHub and writes it
+// as the text files accepted by the --*-file flags, plus the structured
+// per-PR records as context.json.
+func runFetchCommand(args []string) error {
+	cfg := &config{}
+	fs := flag.NewFlagSet("fetch", flag.ExitOnError)
+	registerIssueNumberFlags(fs, cfg)
+	configFile := registerSettingsFlags(fs)
+	outputDir := fs.String("output-dir", ".ai-context", "Directory to write the context files to")
+	if err := parseCommand(fs, configFile, args, cfg); err != nil {
+		return err
+	}
+	if cfg.issueNumber <= 0 {
+		return errors.New("--issue-number is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), appTimeout)
+	defer cancel()
+
+	source, err := newSource(*cfg)
+	if err != nil {
+		return err
+	}
+	issueCtx, prCtx, err := source.Fetch(ctx, cfg.issueNumber, cfg.prNumbers)
+	if err != nil {
+		return err
+	}
+	log.Printf("Fetched issue #%d: %s", cfg.issueNumber, issueCtx.Title)
+	for _, pr := range prCtx.PRs {
+		log.Printf("Fetched PR #%d: %s (%d diff bytes, %d comments)", pr.Number, pr.Title, len(pr.Diff), len(pr.Comments))
+	}
+
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	files := map[string]string{
+		issueTitleFileName: issueCtx.Title,
+		issueBodyFileName:  issueCtx.Body,
+		prTitleFileName:    prCtx.Title,
+		prBodyFileName:     prCtx.Body,
+		diffFileName:       prCtx.Diff,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(*outputDir, name), []byte(content+"\n"), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return writeJSON(filepath.Join(*outputDir, contextFileName), struct {
+		Issue *appctx.IssueContext `json:"issue"`
+		PRs   []appctx.PullRequest `json:"prs"`
+	}{issueCtx, prCtx.PRs})
+}
+
+// runManifestCommand prints the docs manifest.
+func runManifestCommand(args []string) error {
+	cfg := &config{}
+	fs := flag.NewFlagSet("manifest", flag.ExitOnError)
+	registerManifestCacheFlags(fs, cfg)
+	configFile := registerSettingsFlags(fs)
+	output := fs.String("output", "", "Write the manifest to this file instead of stdout")
+	format := fs.String("format", string(docs.ExportJSON), "Output format: json, yaml or csv")
+	if err := parseCommand(fs, configFile, args, cfg); err != nil {
+		return err
+	}
+	if !slices.Contains(docs.ExportFormats, docs.ExportFormat(*format)) {
+		return fmt.Errorf("%w: %q (expected one of %v)", docs.ErrUnknownExportFormat, *format, docs.ExportFormats)
+	}
+
+	manifest, _, err := loadManifest(*cfg)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := manifest.Export(&buf, docs.ExportFormat(*format)); err != nil {
+		return err
+	}
+	return writeOutput(*output, buf.Bytes())
+}
+
+// runIdentifyCommand runs Phase 1 and prints the IdentifyResponse.
+// Input guardrail skips are reported as a response with needs_update false,
+// as they are not failures in a full run either.
+func runIdentifyCommand(args []string) error {
+	cfg := &config{}
+	fs := flag.NewFlagSet("identify", flag.ExitOnError)
+	registerContextFlags(fs, cfg)
+	registerLLMFlags(fs, cfg)
+	registerManifestFileFlag(fs, cfg)
+	registerManifestCacheFlags(fs, cfg)
+	configFile := registerSettingsFlags(fs)
+	output := fs.String("output", "", "Write the response to this file instead of stdout")
+	if err := parseCommand(fs, configFile, args, cfg); err != nil {
+		return err
+	}
+	if err := cfg.requireIssue(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), appTimeout)
+	defer cancel()
+
+	inputGuard := inputGuardrails(cfg.settings)
+	in, err := loadInputs(ctx, *cfg, inputGuard)
+	if err != nil {
+		return err
+	}
+	if reason, err := checkInputs(in, inputGuard, cfg.settings.Limits.MaxDiffSizeBytes); reason != "" {
+		return writeJSON(*output, &openai.IdentifyResponse{
+			Reason: fmt.Sprintf("skipped (%s): %v", reason, err),
+		})
+	}
+
+	manifest, _, err := loadManifest(*cfg)
+	if err != nil {
+		return err
+	}
+	provider, err := newProvider(*cfg)
+	if err != nil {
+		return err
+	}
+
+	candidates := selectCandidates(in, manifest, cfg.settings.Docs.Dir, cfg.settings.Retrieval.TopN)
+	identification, _, err := identify(ctx, provider, in, candidates, inputGuard, cfg.settings.Limits.MaxFilesToUpdate)
+	if err != nil {
+		return err
+	}
+	checkTargets(manifest, in.route, identification)
+	return writeJSON(*output, identification)
+}
+
+// runGenerateCommand runs Phase 2 for one document and writes (or diffs) the result.
+// Issue and PR context are optional here; the instruction is usually enough to debug a prompt.
+func runGenerateCommand(args []string) error {
+	cfg := &config{}
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	registerContextFlags(fs, cfg)
+	registerLLMFlags(fs, cfg)
+	registerWriteFlags(fs, cfg)
+	registerManifestFileFlag(fs, cfg)
+	registerManifestCacheFlags(fs, cfg)
+	configFile := registerSettingsFlags(fs)
+	path := fs.String("path", "", "Document path relative to the docs directory (required)")
+	instruction := fs.String("instruction", "", "What to change in the document (required)")
+	updateType := fs.String("update-type", "modify_section", "Update type: add_inline, modify_section, add_section, add_example")
+	targetLocation := fs.String("target-location", "", "Section or paragraph to change")
+	if err := parseCommand(fs, configFile, args, cfg); err != nil {
+		return err
+	}
+	if *path == "" || *instruction == "" {
+		return errors.New("--path and --instruction are required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), appTimeout)
+	defer cancel()
+
+	inputGuard := inputGuardrails(cfg.settings)
+	in, err := loadInputs(ctx, *cfg, inputGuard)
+	if err != nil {
+		return err
+	}
+	if reason, err := checkInputs(in, inputGuard, cfg.settings.Limits.MaxDiffSizeBytes); reason != "" {
+		return fmt.Errorf("%s: %w", reason, err)
+	}
+
+	manifest, _, err := loadManifest(*cfg)
+	if err != nil {
+		return err
+	}
+	doc := manifest.FindFile(*path)
+	if doc == nil {
+		return fmt.Errorf("%q is not in the docs manifest", *path)
+	}
+	if doc.IsHub {
+		return fmt.Errorf("%w: %s", docs.ErrHubPage, *path)
+	}
+	target, err := resolveTargetLocation(doc, *updateType, *targetLocation)
+	if err != nil {
+		return err
+	}
+	if target != *targetLocation {
+		log.Printf("Corrected target: %q -> %q", *targetLocation, target)
+	}
+	provider, err := newProvider(*cfg)
+	if err != nil {
+		return err
+	}
+
+	gen := &generator{
+		provider:    provider,
+		inputs:      in,
+		manifest:    manifest,
+		docsDir:     cfg.settings.Docs.Dir,
+		inputGuard:  inputGuard,
+		outputGuard: cfg.settings.OutputGuardrails(),
+	}
+	result := gen.generate(ctx, openai.FileToUpdate{
+		Path:             *path,
+		UpdateType:       *updateType,
+		BriefDescription: *instruction,
+		TargetLocation:   target,
+	})
+	if result.skipped() {
+		return fmt.Errorf("%s: %w", result.reason, result.err)
+	}
+
+	writer, closeWriter, err := newDocWriter(*cfg, manifest)
+	if err != nil {
+		return err
+	}
+	defer closeWriter()
+	if err := writer.Write(*path, result.content); err != nil {
+		return err
+	}
+	if cfg.dryRun {
+		log.Printf("Computed diff for: %q", *path)
+	} else {
+		log.Printf("Successfully updated: %q", *path)
+	}
+	return nil
+}
+
+// runValidateCommand runs the output guardrails on existing files.
+// Files containing <updated_document> tags are treated as raw model output.
+func runValidateCommand(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: ai-docs-update validate [flags] FILE...")
+		fs.PrintDefaults()
+	}
+	configFile := registerSettingsFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		return errors.New("at least one file is required")
+	}
+	settings, err := loadSettings(*configFile, fs)
+	if err != nil {
+		return err
+	}
+
+	outputGuard := settings.OutputGuardrails()
+	var failed int
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		content := string(data)
+		if strings.Contains(content, guardrails.OpenTag) {
+			err = outputGuard.Validate(content)
+			content = guardrails.ExtractDocumentContent(content)
+		} else {
+			err = outputGuard.ValidateContent(content)
+		}
+		if err != nil {
+			fmt.Printf("FAIL %s: %v\n", path, err)
+			failed++
+			continue
+		}
+
+		fmt.Printf("OK   %s\n", path)
+		_, warnings := outputGuard.PostProcess(content)
+		for _, w := range warnings {
+			fmt.Printf("     warning: %s\n", w)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed validation", failed, len(paths))
+	}
+	return nil
+}
+
+// runExplainCommand shows the classification of docs and which rule or
+// frontmatter override produced it. Paths are relative to the docs directory.
+func runExplainCommand(args []string) error {
+	cfg := &config{}
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: ai-docs-update explain [flags] PATH...")
+		fmt.Fprintln(fs.Output(), "\nPATH is relative to the docs directory.")
+		fs.PrintDefaults()
+	}
+	configFile := registerSettingsFlags(fs)
+	if err := parseCommand(fs, configFile, args, cfg); err != nil {
+		return err
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		return errors.New("at least one path is required")
+	}
+
+	rules, err := loadRules(*cfg)
+	if err != nil {
+		return err
+	}
+
+	docsDir := cfg.settings.Docs.Dir
+	for i, path := range paths {
+		relPath := filepath.Clean(path)
+		class, err := rules.ClassifyFile(docsDir, relPath)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(relPath)
+		fmt.Printf("  category:     %s\n", class.Category)
+		fmt.Printf("  audience:     %s\n", class.Audience)
+		fmt.Printf("  content type: %s\n", class.ContentType)
+		fmt.Printf("  rule:         %s\n", class.Rule)
+		if class.SDKKind != "" {
+			fmt.Printf("  sdk kind:     %s\n", class.SDKKind)
+		}
+		if class.Platform != "" {
+			fmt.Printf("  platform:     %s\n", class.Platform)
+		}
+		if len(class.Overrides) > 0 {
+			fmt.Printf("  overrides:    %s (frontmatter)\n", strings.Join(class.Overrides, ", "))
+		}
+		excluded := docs.ExcludedBy(relPath, cfg.settings.Docs.ExcludeDirs, cfg.settings.Docs.ExcludeFiles)
+		if class.Exclude {
+			excluded = "ai_exclude (frontmatter)"
+		}
+		if excluded != "" {
+			fmt.Printf("  excluded:     %s\n", excluded)
+		}
+	}
+	return nil
+}
+
+// runLinksCommand prints the link graph between manifest docs, including broken links.
+func runLinksCommand(args []string) error {
+	cfg := &config{}
+	fs := flag.NewFlagSet("links", flag.ExitOnError)
+	registerManifestFileFlag(fs, cfg)
+	registerManifestCacheFlags(fs, cfg)
+	configFile := registerSettingsFlags(fs)
+	output := fs.String("output", "", "Write the link graph to this file instead of stdout")
+	if err := parseCommand(fs, configFile, args, cfg); err != nil {
+		return err
+	}
+
+	_, graph, err := loadManifest(*cfg)
+	if err != nil {
+		return err
+	}
+	for _, b := range graph.Broken {
+		log.Printf("Broken link: %s:%d -> %s (%s)", b.Source, b.Line, b.Target, b.Reason)
+	}
+	return writeJSON(*output, graph)
+}
+
+// runDuplicatesCommand reports docs whose paragraphs repeat a doc they link
+// to, which breaks the single-source-of-truth rule (link, don't repeat).
+func runDuplicatesCommand(args []string) error {
+	cfg := &config{}
+	fs := flag.NewFlagSet("duplicates", flag.ExitOnError)
+	registerManifestFileFlag(fs, cfg)
+	registerManifestCacheFlags(fs, cfg)
+	configFile := registerSettingsFlags(fs)
+	threshold := fs.Float64("threshold", docs.DefaultDuplicateThreshold, "Share of a paragraph's word sequences that must appear in the linked doc (0-1)")
+	jsonOutput := fs.Bool("json", false, "Print the report as JSON")
+	if err := parseCommand(fs, configFile, args, cfg); err != nil {
+		return err
+	}
+	if *threshold <= 0 || *threshold > 1 {
+		return fmt.Errorf("invalid --threshold %v: must be in (0, 1]", *threshold)
+	}
+
+	manifest, graph, err := loadManifest(*cfg)
+	if err != nil {
+		return err
+	}
+	duplicates, err := docs.FindDuplicates(manifest, graph, cfg.settings.Docs.Dir, *threshold)
+	if err != nil {
+		return err
+	}
+
+	if *jsonOutput {
+		return writeJSON("", duplicates)
+	}
+	if len(duplicates) == 0 {
+		fmt.Println("No duplicated content found")
+		return nil
+	}
+	for _, d := range duplicates {
+		fmt.Println(d)
+		for _, p := range d.Passages {
+			fmt.Printf("    %q\n", p)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,441 @@
+// Package config loads the project configuration file (.ai-docs-update.yaml).
+//
+// Values are resolved in this order, later sources winning:
+// built-in defaults, the configuration file, environment variables, command-line flags.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"gopkg.in/yaml.v2"
+
+	appctx "github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/context"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/docs"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/guardrails"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/openai"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/tokenizer"
+)
+
+// DefaultFileName is the configuration file looked up when --config is not set.
+const DefaultFileName = ".ai-docs-update.yaml"
+
+// DefaultStyleGuideDir is the style guide location relative to the docs directory.
+const DefaultStyleGuideDir = "contribution-guide/documentation-style"
+
+// DefaultSidebarsFile is the Docusaurus sidebars location relative to the docs directory.
+const DefaultSidebarsFile = "../sidebars.js"
+
+// DefaultIgnoreLinks are link targets served outside the docs plugin (the API reference).
+var DefaultIgnoreLinks = []string{"/api/**"}
+
+// DefaultRetrievalTopN is the default number of pre-ranked docs sent to Phase 1.
+const DefaultRetrievalTopN = 25
+
+// DefaultSDKRepos are the SDK repositories linked from the SDK docs.
+var DefaultSDKRepos = []SDKRepoConfig{
+	{Repo: "bucketeer-io/android-client-sdk", Platform: "android", SDKKind: docs.SDKKindNative},
+	{Repo: "bucketeer-io/ios-client-sdk", Platform: "ios", SDKKind: docs.SDKKindNative},
+	{Repo: "bucketeer-io/flutter-client-sdk", Platform: "flutter", SDKKind: docs.SDKKindNative},
+	{Repo: "bucketeer-io/javascript-client-sdk", Platform: "javascript", SDKKind: docs.SDKKindNative},
+	{Repo: "bucketeer-io/go-server-sdk", Platform: "go", SDKKind: docs.SDKKindNative},
+	{Repo: "bucketeer-io/node-server-sdk", Platform: "node-js", SDKKind: docs.SDKKindNative},
+	{Repo: "bucketeer-io/openfeature-go-server-sdk", Platform: "go", SDKKind: docs.SDKKindOpenFeature},
+	{Repo: "bucketeer-io/openfeature-swift-client-sdk", Platform: "ios", SDKKind: docs.SDKKindOpenFeature},
+}
+
+// DefaultConcurrency is the default number of parallel Phase 2 generations.
+const DefaultConcurrency = 3
+
+// DefaultSourceRepo is the repository whose issues and PRs drive doc updates.
+const DefaultSourceRepo = "bucketeer-io/bucketeer"
+
+// Config holds all tunable settings.
+// Each field's env tag names the environment variable that overrides it.
+type Config struct {
+	Docs       DocsConfig       `yaml:"docs"`
+	Limits     LimitsConfig     `yaml:"limits"`
+	LLM        LLMConfig        `yaml:"llm"`
+	Generation GenerationConfig `yaml:"generation"`
+	Source     SourceConfig     `yaml:"source"`
+	Retrieval  RetrievalConfig  `yaml:"retrieval"`
+	Diff       DiffConfig       `yaml:"diff"`
+	Redaction  RedactionConfig  `yaml:"redaction"`
+	SDKRepos   []SDKRepoConfig  `yaml:"sdk_repos"`
+}
+
+// DocsConfig configures which documentation files are considered.
+// Relative paths in the configuration file are resolved against the file's directory.
+type DocsConfig struct {
+	Dir           string   `yaml:"dir" env:"AI_DOCS_DOCS_DIR"`
+	ExcludeDirs   []string `yaml:"exclude_dirs" env:"AI_DOCS_EXCLUDE_DIRS"`
+	ExcludeFiles  []string `yaml:"exclude_files" env:"AI_DOCS_EXCLUDE_FILES"`
+	StyleGuideDir string   `yaml:"style_guide_dir" env:"AI_DOCS_STYLE_GUIDE_DIR"` // relative to Dir
+	SidebarsFile  string   `yaml:"sidebars_file" env:"AI_DOCS_SIDEBARS_FILE"`     // relative to Dir; .js or .json, empty to disable
+	RulesFile     string   `yaml:"rules_file" env:"AI_DOCS_RULES_FILE"`           // relative to Dir; empty uses the built-in rules
+	IgnoreLinks   []string `yaml:"ignore_links" env:"AI_DOCS_IGNORE_LINKS"`       // link targets not checked (e.g. plugin routes)
+}
+
+// LimitsConfig holds guardrail limits.
+type LimitsConfig struct {
+	MaxDiffSizeBytes   int `yaml:"max_diff_size_bytes" env:"AI_DOCS_MAX_DIFF_SIZE_BYTES"`
+	MaxChangedFiles    int `yaml:"max_changed_files" env:"AI_DOCS_MAX_CHANGED_FILES"`
+	MaxLinesPerFile    int `yaml:"max_lines_per_file" env:"AI_DOCS_MAX_LINES_PER_FILE"`
+	MaxDocContentBytes int `yaml:"max_doc_content_bytes" env:"AI_DOCS_MAX_DOC_CONTENT_BYTES"`
+	MaxInputTokens     int `yaml:"max_input_tokens" env:"AI_DOCS_MAX_INPUT_TOKENS"` // 0 derives the budget from the model
+	MaxIssueBodyLen    int `yaml:"max_issue_body_len" env:"AI_DOCS_MAX_ISSUE_BODY_LEN"`
+	MaxPRBodyLen       int `yaml:"max_pr_body_len" env:"AI_DOCS_MAX_PR_BODY_LEN"`
+	MaxOutputSize      int `yaml:"max_output_size" env:"AI_DOCS_MAX_OUTPUT_SIZE"`
+	MaxFilesToUpdate   int `yaml:"max_files_to_update" env:"AI_DOCS_MAX_FILES_TO_UPDATE"`
+}
+
+// LLMConfig selects the LLM provider and model.
+// An empty model defers to the provider's own environment variable and default.
+type LLMConfig struct {
+	Provider string `yaml:"provider" env:"LLM_PROVIDER"`
+	Model    string `yaml:"model" env:"AI_DOCS_MODEL"`
+}
+
+// GenerationConfig configures Phase 2.
+type GenerationConfig struct {
+	Concurrency int `yaml:"concurrency" env:"AI_DOCS_CONCURRENCY"`
+}
+
+// SourceConfig configures where issue and PR context is fetched from
+// when an issue number is given instead of context files.
+type SourceConfig struct {
+	Repo   string `yaml:"repo" env:"AI_DOCS_SOURCE_REPO"`
+	APIURL string `yaml:"api_url" env:"GITHUB_API_URL"`
+}
+
+// RetrievalConfig configures the lexical pre-ranking of docs before Phase 1.
+type RetrievalConfig struct {
+	TopN int `yaml:"top_n" env:"AI_DOCS_RETRIEVAL_TOP_N"` // docs sent to Phase 1; 0 sends the whole manifest
+}
+
+// DiffConfig filters the changed files of the PR diff before it is
+// validated, summarized and sent to the LLM. Patterns are doublestar globs
+// matched against repository-relative paths.
+type DiffConfig struct {
+	Include []string `yaml:"include" env:"AI_DOCS_DIFF_INCLUDE"` // empty includes every file
+	Exclude []string `yaml:"exclude" env:"AI_DOCS_DIFF_EXCLUDE"` // applied after include
+}
+
+// RedactionConfig configures the replacement of secrets (API keys, tokens,
+// private keys, passwords) in the issue and PR context before prompting.
+type RedactionConfig struct {
+	Enabled    bool `yaml:"enabled" env:"AI_DOCS_REDACT_SECRETS"`
+	FailClosed bool `yaml:"fail_closed" env:"AI_DOCS_REDACT_FAIL_CLOSED"` // fail the run instead of redacting
+}
+
+// SDKRepoConfig routes changes from an SDK repository to the docs of its
+// platform. Paths limits an entry to changed files matching doublestar globs,
+// so a monorepo can map its packages to different platforms.
+type SDKRepoConfig struct {
+	Repo     string       `yaml:"repo"`     // owner/name
+	Platform string       `yaml:"platform"` // as in the classification rules' platforms
+	SDKKind  docs.SDKKind `yaml:"sdk_kind,omitempty"`
+	Paths    []string     `yaml:"paths,omitempty"`
+}
+
+// Default returns the built-in configuration.
+func Default() *Config {
+	return &Config{
+		Docs: DocsConfig{
+			Dir:           "docs",
+			ExcludeDirs:   append([]string{}, docs.DefaultExcludeDirs...),
+			ExcludeFiles:  append([]string{}, docs.DefaultExcludeFiles...),
+			StyleGuideDir: DefaultStyleGuideDir,
+			SidebarsFile:  DefaultSidebarsFile,
+			IgnoreLinks:   append([]string{}, DefaultIgnoreLinks...),
+		},
+		Limits: LimitsConfig{
+			MaxDiffSizeBytes:   guardrails.MaxDiffSizeBytes,
+			MaxChangedFiles:    guardrails.MaxChangedFiles,
+			MaxLinesPerFile:    guardrails.MaxLinesPerFile,
+			MaxDocContentBytes: guardrails.MaxDocContentBytes,
+			MaxInputTokens:     0,
+			MaxIssueBodyLen:    guardrails.MaxIssueBodyLen,
+			MaxPRBodyLen:       guardrails.MaxPRBodyLen,
+			MaxOutputSize:      guardrails.MaxOutputSize,
+			MaxFilesToUpdate:   openai.DefaultMaxFilesToUpdate,
+		},
+		LLM: LLMConfig{
+			Provider: openai.ProviderName,
+		},
+		Generation: GenerationConfig{
+			Concurrency: DefaultConcurrency,
+		},
+		Source: SourceConfig{
+			Repo:   DefaultSourceRepo,
+			APIURL: appctx.DefaultGitHubAPIURL,
+		},
+		Retrieval: RetrievalConfig{
+			TopN: DefaultRetrievalTopN,
+		},
+		Diff: DiffConfig{
+			Include: []string{},
+			Exclude: append([]string{}, guardrails.DefaultDeniedSourcePaths...),
+		},
+		Redaction: RedactionConfig{
+			Enabled: true,
+		},
+		SDKRepos: append([]SDKRepoConfig{}, DefaultSDKRepos...),
+	}
+}
+
+// Load returns the defaults merged with the configuration file at path.
+// If path is empty, DefaultFileName is used when it exists.
+// A missing explicit path is an error; a missing default file is not.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	explicit := path != ""
+	if !explicit {
+		path = DefaultFileName
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	// Resolve the docs directory relative to the config file, not the working directory
+	if cfg.Docs.Dir != "" && !filepath.IsAbs(cfg.Docs.Dir) {
+		cfg.Docs.Dir = filepath.Join(filepath.Dir(path), cfg.Docs.Dir)
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// ApplyEnv overrides values from environment variables named by env tags.
+// List values are comma-separated; an empty variable is ignored.
+func (c *Config) ApplyEnv() error {
+	return applyEnv(reflect.ValueOf(c).Elem(), os.LookupEnv)
+}
+
+// applyEnv walks struct fields recursively and sets those with a non-empty env variable.
+func applyEnv(v reflect.Value, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field, lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := t.Field(i).Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		value, ok := lookup(name)
+		if !ok || value == "" {
+			continue
+		}
+
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Int:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", name, err)
+			}
+			field.SetInt(int64(n))
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", name, err)
+			}
+			field.SetBool(b)
+		case reflect.Slice:
+			field.Set(reflect.ValueOf(SplitList(value)))
+		}
+	}
+	return nil
+}
+
+// Validate checks that limits are usable.
+func (c *Config) Validate() error {
+	var errs []error
+	positive := map[string]int{
+		"limits.max_diff_size_bytes":   c.Limits.MaxDiffSizeBytes,
+		"limits.max_changed_files":     c.Limits.MaxChangedFiles,
+		"limits.max_lines_per_file":    c.Limits.MaxLinesPerFile,
+		"limits.max_doc_content_bytes": c.Limits.MaxDocContentBytes,
+		"limits.max_issue_body_len":    c.Limits.MaxIssueBodyLen,
+		"limits.max_pr_body_len":       c.Limits.MaxPRBodyLen,
+		"limits.max_output_size":       c.Limits.MaxOutputSize,
+		"limits.max_files_to_update":   c.Limits.MaxFilesToUpdate,
+		"generation.concurrency":       c.Generation.Concurrency,
+	}
+	for name, value := range positive {
+		if value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive, got %d", name, value))
+		}
+	}
+	if c.Limits.MaxInputTokens < 0 {
+		errs = append(errs, fmt.Errorf("limits.max_input_tokens must not be negative, got %d", c.Limits.MaxInputTokens))
+	}
+	if c.Retrieval.TopN < 0 {
+		errs = append(errs, fmt.Errorf("retrieval.top_n must not be negative, got %d", c.Retrieval.TopN))
+	}
+	if c.Docs.Dir == "" {
+		errs = append(errs, errors.New("docs.dir must not be empty"))
+	}
+	for _, pattern := range c.Docs.IgnoreLinks {
+		if !doublestar.ValidatePattern(pattern) {
+			errs = append(errs, fmt.Errorf("docs.ignore_links: invalid pattern %q", pattern))
+		}
+	}
+	for _, pattern := range append(slices.Clone(c.Diff.Include), c.Diff.Exclude...) {
+		if !doublestar.ValidatePattern(pattern) {
+			errs = append(errs, fmt.Errorf("diff: invalid path pattern %q", pattern))
+		}
+	}
+	for i, r := range c.SDKRepos {
+		if r.Repo == "" || r.Platform == "" {
+			errs = append(errs, fmt.Errorf("sdk_repos[%d]: repo and platform are required", i))
+		}
+		if !r.SDKKind.Valid() {
+			errs = append(errs, fmt.Errorf("sdk_repos[%d]: unknown sdk_kind %q", i, r.SDKKind))
+		}
+		for _, pattern := range r.Paths {
+			if !doublestar.ValidatePattern(pattern) {
+				errs = append(errs, fmt.Errorf("sdk_repos[%d]: invalid path pattern %q", i, pattern))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PlatformRoute returns the SDK platforms that a change to repo touching
+// changedPaths documents, or nil if repo is not an SDK repository. Entries
+// with paths apply only if a changed path matches; an entry without paths
+// applies to any change.
+func (c *Config) PlatformRoute(repo string, changedPaths []string) docs.PlatformRoute {
+	var route docs.PlatformRoute
+	for _, r := range c.SDKRepos {
+		if !strings.EqualFold(r.Repo, repo) || !matchesAny(r.Paths, changedPaths) {
+			continue
+		}
+		target := docs.PlatformTarget{Platform: r.Platform, SDKKind: r.SDKKind}
+		if !slices.Contains(route, target) {
+			route = append(route, target)
+		}
+	}
+	return route
+}
+
+// matchesAny reports whether patterns is empty or any path matches one of them.
+func matchesAny(patterns, paths []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range paths {
+		for _, pattern := range patterns {
+			if ok, _ := doublestar.Match(pattern, p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// StyleGuidePath returns the style guide directory resolved against the docs directory.
+func (c *Config) StyleGuidePath() string {
+	if filepath.IsAbs(c.Docs.StyleGuideDir) {
+		return c.Docs.StyleGuideDir
+	}
+	return filepath.Join(c.Docs.Dir, filepath.FromSlash(c.Docs.StyleGuideDir))
+}
+
+// SidebarsPath returns the sidebars file resolved against the docs directory,
+// or "" if sidebars are disabled.
+func (c *Config) SidebarsPath() string {
+	if c.Docs.SidebarsFile == "" || filepath.IsAbs(c.Docs.SidebarsFile) {
+		return c.Docs.SidebarsFile
+	}
+	return filepath.Join(c.Docs.Dir, filepath.FromSlash(c.Docs.SidebarsFile))
+}
+
+// RulesPath returns the classification rules file path, or "" for the built-in rules.
+func (c *Config) RulesPath() string {
+	if c.Docs.RulesFile == "" || filepath.IsAbs(c.Docs.RulesFile) {
+		return c.Docs.RulesFile
+	}
+	return filepath.Join(c.Docs.Dir, filepath.FromSlash(c.Docs.RulesFile))
+}
+
+// InputGuardrails returns input guardrails configured with these limits.
+func (c *Config) InputGuardrails() *guardrails.InputGuardrails {
+	g := guardrails.NewInputGuardrails()
+	g.MaxDiffSizeBytes = c.Limits.MaxDiffSizeBytes
+	g.MaxChangedFiles = c.Limits.MaxChangedFiles
+	g.MaxLinesPerFile = c.Limits.MaxLinesPerFile
+	g.MaxDocContentBytes = c.Limits.MaxDocContentBytes
+	g.MaxInputTokens = c.InputTokenBudget()
+	g.MaxIssueBodyLen = c.Limits.MaxIssueBodyLen
+	g.MaxPRBodyLen = c.Limits.MaxPRBodyLen
+	g.AllowedSourcePaths = c.Diff.Include
+	g.DeniedSourcePaths = c.Diff.Exclude
+	g.RedactSecrets = c.Redaction.Enabled || c.Redaction.FailClosed
+	g.FailOnSecrets = c.Redaction.FailClosed
+	return g
+}
+
+// InputTokenBudget returns limits.max_input_tokens, or if it is 0, the
+// budget of the configured model (guardrails.MaxInputTokens for unknown models).
+func (c *Config) InputTokenBudget() int {
+	if c.Limits.MaxInputTokens > 0 {
+		return c.Limits.MaxInputTokens
+	}
+	if budget, ok := tokenizer.InputBudget(c.LLM.Model); ok {
+		return budget
+	}
+	return guardrails.MaxInputTokens
+}
+
+// OutputGuardrails returns output guardrails configured with these limits.
+func (c *Config) OutputGuardrails() *guardrails.OutputGuardrails {
+	g := guardrails.NewOutputGuardrails()
+	g.MaxOutputSize = c.Limits.MaxOutputSize
+	return g
+}
+
+// YAML returns the configuration as YAML.
+func (c *Config) YAML() (string, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return string(data), nil
+}
+
+// SplitList splits a comma-separated string into a trimmed slice.
+// An empty string yields an empty (non-nil) slice.
+func SplitList(s string) []string {
+	parts := []string{}
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
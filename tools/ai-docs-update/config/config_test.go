@@ -0,0 +1,107 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), DefaultFileName)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeConfig(t, `
+docs:
+  dir: site/docs
+llm:
+  model: file-model
+limits:
+  max_changed_files: 10
+  max_lines_per_file: 500
+generation:
+  concurrency: 2
+`)
+	t.Setenv("AI_DOCS_MODEL", "")
+	t.Setenv("AI_DOCS_MAX_CHANGED_FILES", "")
+	t.Setenv("AI_DOCS_MAX_LINES_PER_FILE", "800")
+	t.Setenv("AI_DOCS_DIFF_EXCLUDE", "**/*.lock, gen/**")
+	t.Setenv("AI_DOCS_REDACT_FAIL_CLOSED", "true")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := cfg.ApplyEnv(); err != nil {
+		t.Fatalf("ApplyEnv() error = %v", err)
+	}
+
+	defaults := Default()
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"default kept", cfg.Limits.MaxDiffSizeBytes, defaults.Limits.MaxDiffSizeBytes},
+		{"file over default", cfg.Limits.MaxChangedFiles, 10},
+		{"env over file", cfg.Limits.MaxLinesPerFile, 800},
+		{"file model without env", cfg.LLM.Model, "file-model"},
+		{"file concurrency", cfg.Generation.Concurrency, 2},
+		{"env bool", cfg.Redaction.FailClosed, true},
+		{"docs dir relative to the file", cfg.Docs.Dir, filepath.Join(filepath.Dir(path), "site/docs")},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+	if want := []string{"**/*.lock", "gen/**"}; !slices.Equal(cfg.Diff.Exclude, want) {
+		t.Errorf("env list: Diff.Exclude = %q, want %q", cfg.Diff.Exclude, want)
+	}
+}
+
+func TestApplyEnvModel(t *testing.T) {
+	cfg := Default()
+	cfg.LLM.Model = "file-model"
+	t.Setenv("AI_DOCS_MODEL", "env-model")
+	if err := cfg.ApplyEnv(); err != nil {
+		t.Fatalf("ApplyEnv() error = %v", err)
+	}
+	if cfg.LLM.Model != "env-model" {
+		t.Errorf("LLM.Model = %q, want AI_DOCS_MODEL over the file", cfg.LLM.Model)
+	}
+}
+
+func TestApplyEnvInvalid(t *testing.T) {
+	t.Setenv("AI_DOCS_MAX_CHANGED_FILES", "many")
+	if err := Default().ApplyEnv(); err == nil {
+		t.Error("ApplyEnv() error = nil, want an invalid integer error")
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Load() of a missing explicit file: error = nil, want an error")
+	}
+
+	t.Chdir(t.TempDir())
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load(\"\") without a default file: error = %v", err)
+	}
+	if cfg.Docs.Dir != Default().Docs.Dir {
+		t.Errorf("Docs.Dir = %q, want the default", cfg.Docs.Dir)
+	}
+}
+
+func TestLoadUnknownKey(t *testing.T) {
+	if _, err := Load(writeConfig(t, "llm:\n  modle: gpt-4o\n")); err == nil {
+		t.Error("Load() with a misspelled key: error = nil, want an error")
+	}
+}
@@ -0,0 +1,345 @@
+package context
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultGitHubAPIURL is the public GitHub REST API.
+	DefaultGitHubAPIURL = "https://api.github.com"
+
+	// EnvGitHubAPIURL overrides the API base URL (set automatically in GitHub Actions).
+	EnvGitHubAPIURL = "GITHUB_API_URL"
+
+	// EnvGitHubToken is the token used for authenticated requests.
+	EnvGitHubToken = "GITHUB_TOKEN"
+
+	// EnvGHToken is the gh CLI token variable, used when GITHUB_TOKEN is not set.
+	EnvGHToken = "GH_TOKEN"
+
+	// githubTimeout bounds each API request.
+	githubTimeout = 30 * time.Second
+
+	// maxCommentPages caps comment pagination (100 comments per page).
+	maxCommentPages = 5
+
+	// maxTimelinePages caps issue timeline pagination (100 events per page).
+	maxTimelinePages = 10
+)
+
+// repoPattern matches "owner/name" repository names.
+var repoPattern = regexp.MustCompile(`^[\w.-]+/[\w.-]+$`)
+
+// ErrInvalidRepo indicates a repository name that is not "owner/name".
+var ErrInvalidRepo = errors.New("invalid repository (expected owner/name)")
+
+// GitHubError is a non-2xx response from the GitHub API.
+type GitHubError struct {
+	StatusCode int
+	Path       string
+	Message    string
+}
+
+func (e *GitHubError) Error() string {
+	return fmt.Sprintf("github API %s: status %d: %s", e.Path, e.StatusCode, e.Message)
+}
+
+// GitHubSource fetches issue and pull request context from the GitHub REST API.
+type GitHubSource struct {
+	repo        string
+	baseURL     string
+	token       string
+	httpClient  *http.Client
+	discoverPRs bool
+}
+
+// GitHubOption configures a GitHubSource.
+type GitHubOption func(*GitHubSource)
+
+// WithBaseURL sets the API base URL (e.g. a local stand-in for tests).
+func WithBaseURL(baseURL string) GitHubOption {
+	return func(s *GitHubSource) {
+		if baseURL != "" {
+			s.baseURL = strings.TrimRight(baseURL, "/")
+		}
+	}
+}
+
+// WithToken sets the API token.
+func WithToken(token string) GitHubOption {
+	return func(s *GitHubSource) {
+		s.token = token
+	}
+}
+
+// WithPRDiscovery makes Fetch use the merged pull requests that
+// cross-reference the issue when no PR numbers are given.
+func WithPRDiscovery(enabled bool) GitHubOption {
+	return func(s *GitHubSource) {
+		s.discoverPRs = enabled
+	}
+}
+
+// WithHTTPClient sets the HTTP client.
+func WithHTTPClient(client *http.Client) GitHubOption {
+	return func(s *GitHubSource) {
+		s.httpClient = client
+	}
+}
+
+// NewGitHubSource creates a source for repo ("owner/name").
+// The base URL and token default to $GITHUB_API_URL and $GITHUB_TOKEN (or $GH_TOKEN).
+func NewGitHubSource(repo string, opts ...GitHubOption) (*GitHubSource, error) {
+	if !repoPattern.MatchString(repo) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRepo, repo)
+	}
+
+	token := os.Getenv(EnvGitHubToken)
+	if token == "" {
+		token = os.Getenv(EnvGHToken)
+	}
+	s := &GitHubSource{
+		repo:       repo,
+		baseURL:    DefaultGitHubAPIURL,
+		token:      token,
+		httpClient: &http.Client{Timeout: githubTimeout},
+	}
+	WithBaseURL(os.Getenv(EnvGitHubAPIURL))(s)
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// githubUser is the author of an issue, pull request or comment.
+type githubUser struct {
+	Login string `json:"login"`
+	Type  string `json:"type"`
+}
+
+// githubIssue is the subset of the issue and pull request payloads we use.
+type githubIssue struct {
+	Number  int        `json:"number"`
+	Title   string     `json:"title"`
+	Body    string     `json:"body"`
+	HTMLURL string     `json:"html_url"`
+	Merged  bool       `json:"merged"`
+	User    githubUser `json:"user"`
+}
+
+// githubComment is a conversation comment.
+type githubComment struct {
+	Body string     `json:"body"`
+	User githubUser `json:"user"`
+}
+
+// githubTimelineEvent is an issue timeline event; only cross-references are used.
+type githubTimelineEvent struct {
+	Event  string `json:"event"`
+	Source struct {
+		Issue struct {
+			Number      int             `json:"number"`
+			PullRequest json.RawMessage `json:"pull_request"`
+			Repository  struct {
+				FullName string `json:"full_name"`
+			} `json:"repository"`
+		} `json:"issue"`
+	} `json:"source"`
+}
+
+// Fetch implements Source. When prNumbers is empty, no pull requests are
+// fetched unless discovery is enabled (WithPRDiscovery): then the merged pull
+// requests that cross-reference the issue in the same repository are used.
+func (s *GitHubSource) Fetch(ctx context.Context, issueNumber int, prNumbers []int) (*IssueContext, *PRContext, error) {
+	issue, err := s.FetchIssue(ctx, issueNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	discover := len(prNumbers) == 0 && s.discoverPRs
+	if discover {
+		prNumbers, err = s.LinkedPRs(ctx, issueNumber)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	prs := make([]PullRequest, 0, len(prNumbers))
+	for _, number := range prNumbers {
+		pr, err := s.FetchPR(ctx, number)
+		if err != nil {
+			return nil, nil, err
+		}
+		if discover && !pr.Merged {
+			// Closed or still open: the docs describe what shipped
+			log.Printf("Skipping unmerged PR #%d linked to issue #%d", number, issueNumber)
+			continue
+		}
+		prs = append(prs, *pr)
+	}
+	if discover && len(prs) > 0 {
+		numbers := make([]int, len(prs))
+		for i, pr := range prs {
+			numbers[i] = pr.Number
+		}
+		log.Printf("Found merged PRs linked to issue #%d: %v", issueNumber, numbers)
+	}
+	return issue, NewPRContext(prs), nil
+}
+
+// FetchIssue fetches an issue and its comments. The comments are also
+// appended to the body, as NewPRContext does for pull requests, so that they
+// reach the prompts.
+func (s *GitHubSource) FetchIssue(ctx context.Context, number int) (*IssueContext, error) {
+	var issue githubIssue
+	if err := s.getJSON(ctx, fmt.Sprintf("/repos/%s/issues/%d", s.repo, number), &issue); err != nil {
+		return nil, fmt.Errorf("failed to fetch issue #%d: %w", number, err)
+	}
+	comments, err := s.fetchComments(ctx, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch comments for issue #%d: %w", number, err)
+	}
+	return &IssueContext{
+		Number:   issue.Number,
+		Title:    strings.TrimSpace(issue.Title),
+		Body:     withComments(issue.Body, comments),
+		Comments: comments,
+	}, nil
+}
+
+// FetchPR fetches a pull request with its diff and conversation comments.
+// A diff that cannot be fetched (e.g. too large for the API) is logged and left empty.
+func (s *GitHubSource) FetchPR(ctx context.Context, number int) (*PullRequest, error) {
+	path := fmt.Sprintf("/repos/%s/pulls/%d", s.repo, number)
+	var pr githubIssue
+	if err := s.getJSON(ctx, path, &pr); err != nil {
+		return nil, fmt.Errorf("failed to fetch PR #%d: %w", number, err)
+	}
+
+	diff, err := s.get(ctx, path, "application/vnd.github.diff")
+	if err != nil {
+		log.Printf("Warning: Failed to fetch diff for PR #%d: %v", number, err)
+	}
+
+	// PR conversation comments live on the issue endpoint
+	comments, err := s.fetchComments(ctx, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch comments for PR #%d: %w", number, err)
+	}
+
+	return &PullRequest{
+		Number:   pr.Number,
+		Title:    strings.TrimSpace(pr.Title),
+		Body:     strings.TrimSpace(pr.Body),
+		URL:      pr.HTMLURL,
+		Merged:   pr.Merged,
+		Diff:     string(diff),
+		Comments: comments,
+	}, nil
+}
+
+// LinkedPRs returns the pull requests in the same repository that
+// cross-reference the issue, in timeline order.
+func (s *GitHubSource) LinkedPRs(ctx context.Context, issueNumber int) ([]int, error) {
+	var numbers []int
+	seen := make(map[int]bool)
+	for page := 1; page <= maxTimelinePages; page++ {
+		var events []githubTimelineEvent
+		path := fmt.Sprintf("/repos/%s/issues/%d/timeline?per_page=100&page=%d", s.repo, issueNumber, page)
+		if err := s.getJSON(ctx, path, &events); err != nil {
+			return nil, fmt.Errorf("failed to fetch timeline for issue #%d: %w", issueNumber, err)
+		}
+		for _, e := range events {
+			src := e.Source.Issue
+			if e.Event != "cross-referenced" || len(src.PullRequest) == 0 ||
+				!strings.EqualFold(src.Repository.FullName, s.repo) || seen[src.Number] {
+				continue
+			}
+			seen[src.Number] = true
+			numbers = append(numbers, src.Number)
+		}
+		if len(events) < 100 {
+			break
+		}
+	}
+	return numbers, nil
+}
+
+// fetchComments returns the human conversation comments on an issue or PR.
+// Comments from bots (CI, coverage, etc.) are skipped.
+func (s *GitHubSource) fetchComments(ctx context.Context, number int) ([]Comment, error) {
+	var comments []Comment
+	for page := 1; page <= maxCommentPages; page++ {
+		var batch []githubComment
+		path := fmt.Sprintf("/repos/%s/issues/%d/comments?per_page=100&page=%d", s.repo, number, page)
+		if err := s.getJSON(ctx, path, &batch); err != nil {
+			return nil, err
+		}
+		for _, c := range batch {
+			if c.User.Type == "Bot" || strings.TrimSpace(c.Body) == "" {
+				continue
+			}
+			comments = append(comments, Comment{Author: c.User.Login, Body: strings.TrimSpace(c.Body)})
+		}
+		if len(batch) < 100 {
+			break
+		}
+	}
+	return comments, nil
+}
+
+// getJSON fetches path and decodes the JSON response into v.
+func (s *GitHubSource) getJSON(ctx context.Context, path string, v any) error {
+	data, err := s.get(ctx, path, "application/vnd.github+json")
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse response from %s: %w", path, err)
+	}
+	return nil
+}
+
+// get performs a GET request and returns the body of a 2xx response.
+func (s *GitHubSource) get(ctx context.Context, path, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("github API %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from %s: %w", path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var body struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &body) != nil || body.Message == "" {
+			body.Message = strings.TrimSpace(string(data))
+		}
+		return nil, &GitHubError{StatusCode: resp.StatusCode, Path: path, Message: body.Message}
+	}
+	return data, nil
+}
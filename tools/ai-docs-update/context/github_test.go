@@ -0,0 +1,166 @@
+package context
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeGitHub serves an issue with a two-page timeline and comments, and the
+// pull requests it cross-references. All of them are merged except #14.
+func fakeGitHub(t *testing.T) *httptest.Server {
+	t.Helper()
+	timeline := make([]map[string]any, 0, 130)
+	for i := 0; i < 130; i++ {
+		timeline = append(timeline, map[string]any{"event": "commented"})
+	}
+	crossRef := func(number int, repo string) map[string]any {
+		return map[string]any{
+			"event": "cross-referenced",
+			"source": map[string]any{"issue": map[string]any{
+				"number":       number,
+				"pull_request": map[string]any{"url": "x"},
+				"repository":   map[string]any{"full_name": repo},
+			}},
+		}
+	}
+	timeline[3] = crossRef(10, "bucketeer-io/bucketeer")
+	timeline[50] = crossRef(14, "bucketeer-io/bucketeer")
+	timeline[120] = crossRef(11, "bucketeer-io/bucketeer")
+	timeline[125] = crossRef(12, "bucketeer-io/other")
+	timeline[128] = crossRef(10, "bucketeer-io/bucketeer")
+
+	comments := []map[string]any{
+		{"body": "Also covers the web SDK.", "user": map[string]any{"login": "alice", "type": "User"}},
+		{"body": "Coverage: 80%", "user": map[string]any{"login": "codecov", "type": "Bot"}},
+		{"body": "  ", "user": map[string]any{"login": "bob", "type": "User"}},
+	}
+
+	mux := http.NewServeMux()
+	writeJSON := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+	mux.HandleFunc("/repos/bucketeer-io/bucketeer/issues/1", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"number": 1, "title": " Document flag triggers ", "body": "Add docs.\n"})
+	})
+	mux.HandleFunc("/repos/bucketeer-io/bucketeer/issues/1/timeline", func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		start := min((page-1)*100, len(timeline))
+		writeJSON(w, timeline[start:min(start+100, len(timeline))])
+	})
+	mux.HandleFunc("/repos/bucketeer-io/bucketeer/issues/{number}/comments", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("number") == "1" {
+			writeJSON(w, comments)
+			return
+		}
+		writeJSON(w, []any{})
+	})
+	mux.HandleFunc("/repos/bucketeer-io/bucketeer/pulls/{number}", func(w http.ResponseWriter, r *http.Request) {
+		number := r.PathValue("number")
+		if strings.Contains(r.Header.Get("Accept"), "diff") {
+			fmt.Fprintf(w, "diff --git a/f%s.go b/f%s.go\n", number, number)
+			return
+		}
+		writeJSON(w, map[string]any{"number": json.Number(number), "title": "PR " + number, "body": "Body " + number, "merged": number != "14"})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGitHubSourceFetch(t *testing.T) {
+	srv := fakeGitHub(t)
+	s, err := NewGitHubSource("bucketeer-io/bucketeer", WithBaseURL(srv.URL), WithToken(""), WithPRDiscovery(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issue, pr, err := s.Fetch(context.Background(), 1, nil)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	if issue.Title != "Document flag triggers" {
+		t.Errorf("issue title = %q", issue.Title)
+	}
+	if want := "Add docs.\n\nComments:\n@alice: Also covers the web SDK."; issue.Body != want {
+		t.Errorf("issue body = %q, want %q", issue.Body, want)
+	}
+	if len(issue.Comments) != 1 {
+		t.Errorf("issue comments = %v, want the one human comment", issue.Comments)
+	}
+
+	var numbers []int
+	for _, p := range pr.PRs {
+		numbers = append(numbers, p.Number)
+	}
+	if want := []int{10, 11}; !slices.Equal(numbers, want) {
+		t.Errorf("linked PRs = %v, want %v (merged, same repository, both timeline pages)", numbers, want)
+	}
+	if want := "--- PR #10 ---\nPR 10\n--- PR #11 ---\nPR 11"; pr.Title != want {
+		t.Errorf("PR title = %q, want %q", pr.Title, want)
+	}
+	if want := "diff --git a/f10.go b/f10.go\ndiff --git a/f11.go b/f11.go"; pr.Diff != want {
+		t.Errorf("PR diff = %q, want %q", pr.Diff, want)
+	}
+}
+
+func TestGitHubSourcePRNumbers(t *testing.T) {
+	srv := fakeGitHub(t)
+	tests := []struct {
+		name      string
+		discover  bool
+		prNumbers []int
+		want      []int
+	}{
+		{name: "no PR numbers", want: nil},
+		{name: "discovered", discover: true, want: []int{10, 11}},
+		{name: "given", prNumbers: []int{14, 12}, want: []int{14, 12}},
+		{name: "given with discovery", discover: true, prNumbers: []int{14}, want: []int{14}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewGitHubSource("bucketeer-io/bucketeer", WithBaseURL(srv.URL), WithToken(""), WithPRDiscovery(tt.discover))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, pr, err := s.Fetch(context.Background(), 1, tt.prNumbers)
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			var numbers []int
+			for _, p := range pr.PRs {
+				numbers = append(numbers, p.Number)
+			}
+			if !slices.Equal(numbers, tt.want) {
+				t.Errorf("PRs = %v, want %v", numbers, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitHubSourceError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "Not Found"}`)
+	}))
+	defer srv.Close()
+
+	s, err := NewGitHubSource("bucketeer-io/bucketeer", WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = s.Fetch(context.Background(), 1, nil)
+	var ghErr *GitHubError
+	if !errors.As(err, &ghErr) || ghErr.StatusCode != http.StatusNotFound || ghErr.Message != "Not Found" {
+		t.Errorf("Fetch() error = %v, want a 404 GitHubError", err)
+	}
+}
@@ -9,15 +9,20 @@ import (
 
 // IssueContext holds the context from a GitHub issue.
 type IssueContext struct {
-	Title string
-	Body  string
+	Number   int       `json:"number,omitempty"`
+	Title    string    `json:"title"`
+	Body     string    `json:"body"`
+	Comments []Comment `json:"comments,omitempty"`
 }
 
-// PRContext holds the context from a GitHub pull request.
+// PRContext holds the context from one or more GitHub pull requests.
+// Title, Body and Diff combine all pull requests; PRs keeps the per-PR records
+// when the context was fetched from GitHub (it is empty when loaded from files).
 type PRContext struct {
-	Title string
-	Body  string
-	Diff  string
+	Title string        `json:"title"`
+	Body  string        `json:"body"`
+	Diff  string        `json:"diff"`
+	PRs   []PullRequest `json:"prs,omitempty"`
 }
 
 // LoadIssue loads issue context from the specified files.
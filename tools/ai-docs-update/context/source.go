@@ -0,0 +1,93 @@
+package context
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Source provides the issue and pull request context for a run.
+type Source interface {
+	// Fetch returns the issue and the given pull requests. Implementations may
+	// be configured to discover the issue's pull requests when prNumbers is empty.
+	Fetch(ctx context.Context, issueNumber int, prNumbers []int) (*IssueContext, *PRContext, error)
+}
+
+// Comment is a single conversation comment on an issue or pull request.
+type Comment struct {
+	Author string `json:"author"`
+	Body   string `json:"body"`
+}
+
+// PullRequest is the structured context of one pull request.
+type PullRequest struct {
+	Number   int       `json:"number"`
+	Title    string    `json:"title"`
+	Body     string    `json:"body"`
+	URL      string    `json:"url,omitempty"`
+	Merged   bool      `json:"merged"`
+	Diff     string    `json:"diff"`
+	Comments []Comment `json:"comments,omitempty"`
+}
+
+// NewPRContext combines pull requests into a PRContext. Titles and bodies are
+// separated by "--- PR #N ---" markers and diffs are concatenated, matching the
+// files written by the workflow, so prompts look the same for either source.
+func NewPRContext(prs []PullRequest) *PRContext {
+	var titles, bodies, diffs []string
+	for _, pr := range prs {
+		marker := fmt.Sprintf("--- PR #%d ---", pr.Number)
+		titles = append(titles, marker+"\n"+strings.TrimSpace(pr.Title))
+
+		bodies = append(bodies, marker+"\n"+withComments(pr.Body, pr.Comments))
+
+		if diff := strings.TrimSpace(pr.Diff); diff != "" {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	return &PRContext{
+		Title: strings.Join(titles, "\n"),
+		Body:  strings.Join(bodies, "\n\n"),
+		Diff:  strings.Join(diffs, "\n"),
+		PRs:   prs,
+	}
+}
+
+// withComments returns body followed by the comments as "@author: text" lines.
+func withComments(body string, comments []Comment) string {
+	body = strings.TrimSpace(body)
+	if len(comments) == 0 {
+		return body
+	}
+	var sb strings.Builder
+	sb.WriteString(body)
+	sb.WriteString("\n\nComments:")
+	for _, c := range comments {
+		fmt.Fprintf(&sb, "\n@%s: %s", c.Author, strings.TrimSpace(c.Body))
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// FileSource loads context from files, such as those written by the fetch command.
+// The issue and PR numbers passed to Fetch are ignored.
+type FileSource struct {
+	IssueTitleFile string
+	IssueBodyFile  string
+	PRTitleFile    string
+	PRBodyFile     string
+	DiffFile       string
+}
+
+// Fetch implements Source.
+func (s *FileSource) Fetch(_ context.Context, _ int, _ []int) (*IssueContext, *PRContext, error) {
+	issue, err := LoadIssue(s.IssueTitleFile, s.IssueBodyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load issue context: %w", err)
+	}
+	pr, err := LoadPR(s.PRTitleFile, s.PRBodyFile, s.DiffFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load PR context: %w", err)
+	}
+	return issue, pr, nil
+}
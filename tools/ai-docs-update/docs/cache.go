@@ -0,0 +1,129 @@
+package docs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// cacheVersion is bumped whenever parseDocFile's output changes,
+// so caches written by older versions are discarded.
+const cacheVersion = 5
+
+// ManifestCache stores parsed doc metadata keyed by content hash, so
+// GenerateManifest re-parses only the files that changed since the cache was
+// written. Entries are stored before sidebar and link graph data is applied.
+type ManifestCache struct {
+	Version   int                   `json:"version"`
+	RulesHash string                `json:"rules_hash"` // Classification rules the entries were built with
+	Entries   map[string]CacheEntry `json:"entries"`    // Keyed by path relative to the docs directory
+
+	reused, parsed int
+}
+
+// CacheEntry is the cached parse result of one doc file.
+type CacheEntry struct {
+	Hash string   `json:"hash"`          // SHA-256 of the file content
+	Doc  *DocFile `json:"doc,omitempty"` // nil if the doc excludes itself with ai_exclude
+}
+
+// NewManifestCache returns an empty cache.
+func NewManifestCache() *ManifestCache {
+	return &ManifestCache{Version: cacheVersion, Entries: make(map[string]CacheEntry)}
+}
+
+// LoadManifestCache reads a cache written by Save. A missing file or a cache
+// written by another version gives an empty cache.
+func LoadManifestCache(path string) (*ManifestCache, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewManifestCache(), nil
+		}
+		return nil, fmt.Errorf("failed to read manifest cache: %w", err)
+	}
+
+	var c ManifestCache
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest cache %s: %w", path, err)
+	}
+	if c.Version != cacheVersion || c.Entries == nil {
+		return NewManifestCache(), nil
+	}
+	return &c, nil
+}
+
+// Save writes the cache to path, creating the directory if needed.
+func (c *ManifestCache) Save(path string) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create manifest cache directory: %w", err)
+	}
+
+	// Write to a temporary file first so an interrupted run never leaves a truncated cache
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest cache: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write manifest cache: %w", err)
+	}
+	return nil
+}
+
+// Stats returns how many files the last GenerateManifest reused from the
+// cache and how many it parsed.
+func (c *ManifestCache) Stats() (reused, parsed int) {
+	return c.reused, c.parsed
+}
+
+// useRules drops all entries if they were built with different rules.
+func (c *ManifestCache) useRules(rules *Rules) {
+	hash := rules.hash()
+	if c.RulesHash != hash {
+		c.RulesHash = hash
+		c.Entries = make(map[string]CacheEntry)
+	}
+}
+
+// parse returns the cached entry for relPath if its content is unchanged,
+// otherwise parses the file and stores the result.
+func (c *ManifestCache) parse(relPath string, data []byte, parse func() (*DocFile, error)) (*DocFile, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	if entry, ok := c.Entries[relPath]; ok && entry.Hash == hash {
+		c.reused++
+		return entry.Doc, nil
+	}
+
+	doc, err := parse()
+	if err != nil {
+		delete(c.Entries, relPath)
+		return nil, err
+	}
+	c.parsed++
+	c.Entries[relPath] = CacheEntry{Hash: hash, Doc: doc}
+	return doc, nil
+}
+
+// prune removes entries for files not in seen (deleted or now excluded).
+func (c *ManifestCache) prune(seen map[string]bool) {
+	for path := range c.Entries {
+		if !seen[path] {
+			delete(c.Entries, path)
+		}
+	}
+}
+
+// hash returns a fingerprint of the rules for cache invalidation.
+func (r *Rules) hash() string {
+	data, _ := json.Marshal(r)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
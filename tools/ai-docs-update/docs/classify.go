@@ -0,0 +1,219 @@
+package docs
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/frontmatter"
+	"github.com/bmatcuk/doublestar/v4"
+	"gopkg.in/yaml.v2"
+)
+
+// defaultRulesYAML holds the built-in classification rules.
+//
+//go:embed classification.yaml
+var defaultRulesYAML []byte
+
+// ErrInvalidRules indicates a classification rules file that cannot be used.
+var ErrInvalidRules = errors.New("invalid classification rules")
+
+// Classification used for docs that match no rule.
+const (
+	DefaultCategory    = "general"
+	DefaultAudience    = "all"
+	DefaultContentType = ContentTypeUserGuide
+)
+
+// SDKKind distinguishes native SDK docs from OpenFeature provider docs.
+type SDKKind string
+
+const (
+	// SDKKindNative is for docs of Bucketeer's own SDKs.
+	SDKKindNative SDKKind = "native"
+	// SDKKindOpenFeature is for docs of Bucketeer's OpenFeature providers.
+	SDKKindOpenFeature SDKKind = "openfeature"
+)
+
+// Valid reports whether k is empty or one of the known SDK kinds.
+func (k SDKKind) Valid() bool {
+	return k == "" || k == SDKKindNative || k == SDKKindOpenFeature
+}
+
+// Rule classifies the docs whose path matches a glob.
+type Rule struct {
+	Match       string      `yaml:"match"` // doublestar glob relative to the docs directory
+	Category    string      `yaml:"category"`
+	Audience    string      `yaml:"audience"`
+	ContentType ContentType `yaml:"content_type"`
+	SDKKind     SDKKind     `yaml:"sdk_kind"` // empty for non-SDK docs
+}
+
+// PlatformRule assigns an SDK platform to the docs matching any of its globs.
+type PlatformRule struct {
+	Platform string   `yaml:"platform"`
+	Match    []string `yaml:"match"`
+}
+
+// Rules is an ordered list of classification rules; the first match wins.
+// Platforms are matched separately, also first match wins.
+type Rules struct {
+	Rules     []Rule         `yaml:"rules"`
+	Platforms []PlatformRule `yaml:"platforms"`
+}
+
+// Classification is the result of classifying a doc, with where each value came from.
+type Classification struct {
+	Category    string      `json:"category"`
+	Audience    string      `json:"audience"`
+	ContentType ContentType `json:"content_type"`
+	SDKKind     SDKKind     `json:"sdk_kind,omitempty"`
+	Platform    string      `json:"platform,omitempty"`
+	Exclude     bool        `json:"exclude"`
+	Rule        string      `json:"rule"`                // Matching rule (e.g. "rule 3: open-feature/**") or "default"
+	Overrides   []string    `json:"overrides,omitempty"` // Frontmatter keys that overrode the rule
+}
+
+// DefaultRules returns the built-in rules (classification.yaml).
+func DefaultRules() *Rules {
+	rules, err := ParseRules(defaultRulesYAML)
+	if err != nil {
+		panic(fmt.Sprintf("built-in classification rules: %v", err))
+	}
+	return rules
+}
+
+// LoadRules reads classification rules from a YAML file.
+func LoadRules(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read classification rules: %w", err)
+	}
+	rules, err := ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// ParseRules parses and validates classification rules.
+func ParseRules(data []byte) (*Rules, error) {
+	var rules Rules
+	if err := yaml.UnmarshalStrict(data, &rules); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRules, err)
+	}
+	for i, r := range rules.Rules {
+		if r.Match == "" || !doublestar.ValidatePattern(r.Match) {
+			return nil, fmt.Errorf("%w: rule %d: invalid match pattern %q", ErrInvalidRules, i+1, r.Match)
+		}
+		if r.Category == "" || r.Audience == "" {
+			return nil, fmt.Errorf("%w: rule %d (%s): category and audience are required", ErrInvalidRules, i+1, r.Match)
+		}
+		if !r.ContentType.Valid() {
+			return nil, fmt.Errorf("%w: rule %d (%s): unknown content_type %q", ErrInvalidRules, i+1, r.Match, r.ContentType)
+		}
+		if !r.SDKKind.Valid() {
+			return nil, fmt.Errorf("%w: rule %d (%s): unknown sdk_kind %q", ErrInvalidRules, i+1, r.Match, r.SDKKind)
+		}
+	}
+	for _, p := range rules.Platforms {
+		if p.Platform == "" || len(p.Match) == 0 {
+			return nil, fmt.Errorf("%w: platforms: platform and match are required", ErrInvalidRules)
+		}
+		for _, pattern := range p.Match {
+			if !doublestar.ValidatePattern(pattern) {
+				return nil, fmt.Errorf("%w: platform %s: invalid match pattern %q", ErrInvalidRules, p.Platform, pattern)
+			}
+		}
+	}
+	return &rules, nil
+}
+
+// Valid reports whether t is one of the known content types.
+func (t ContentType) Valid() bool {
+	switch t {
+	case ContentTypeUserGuide, ContentTypeAdminConfig, ContentTypeDeveloperRef:
+		return true
+	}
+	return false
+}
+
+// Classify returns the category, audience and content type for a doc path
+// (relative to the docs directory, slash- or OS-separated), applying the
+// frontmatter overrides. An invalid ai_content_type override is ignored
+// with a warning.
+func (r *Rules) Classify(path string, fm FrontMatter) Classification {
+	c := r.match(filepath.ToSlash(path))
+	c.Platform = r.platform(filepath.ToSlash(path))
+
+	if fm.AIContentType != "" {
+		if t := ContentType(fm.AIContentType); t.Valid() {
+			c.ContentType = t
+			c.Overrides = append(c.Overrides, "ai_content_type")
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: %s: ignoring unknown ai_content_type %q\n", path, fm.AIContentType)
+		}
+	}
+	if fm.AIAudience != "" {
+		c.Audience = fm.AIAudience
+		c.Overrides = append(c.Overrides, "ai_audience")
+	}
+	if fm.AIExclude {
+		c.Exclude = true
+		c.Overrides = append(c.Overrides, "ai_exclude")
+	}
+	return c
+}
+
+// match returns the classification of the first rule matching path.
+func (r *Rules) match(path string) Classification {
+	for i, rule := range r.Rules {
+		if ok, _ := doublestar.Match(rule.Match, path); ok {
+			return Classification{
+				Category:    rule.Category,
+				Audience:    rule.Audience,
+				ContentType: rule.ContentType,
+				SDKKind:     rule.SDKKind,
+				Rule:        fmt.Sprintf("rule %d: %s", i+1, rule.Match),
+			}
+		}
+	}
+	return Classification{
+		Category:    DefaultCategory,
+		Audience:    DefaultAudience,
+		ContentType: DefaultContentType,
+		Rule:        "default",
+	}
+}
+
+// platform returns the platform of the first platform rule matching path, or "".
+func (r *Rules) platform(path string) string {
+	for _, p := range r.Platforms {
+		for _, pattern := range p.Match {
+			if ok, _ := doublestar.Match(pattern, path); ok {
+				return p.Platform
+			}
+		}
+	}
+	return ""
+}
+
+// ClassifyFile reads a doc's frontmatter and classifies it.
+// relPath is relative to docsDir.
+func (r *Rules) ClassifyFile(docsDir, relPath string) (Classification, error) {
+	if FormatOf(relPath) == "" {
+		return Classification{}, fmt.Errorf("not a documentation file (.md or .mdx): %s", relPath)
+	}
+	file, err := os.Open(filepath.Join(docsDir, relPath))
+	if err != nil {
+		return Classification{}, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	// Unparseable frontmatter means no overrides, as in GenerateManifest
+	var fm FrontMatter
+	_, _ = frontmatter.Parse(file, &fm)
+	return r.Classify(relPath, fm), nil
+}
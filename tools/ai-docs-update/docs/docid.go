@@ -0,0 +1,97 @@
+package docs
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Format is the source format of a documentation file, derived from its extension.
+type Format string
+
+const (
+	// FormatMarkdown is a .md file.
+	FormatMarkdown Format = "md"
+	// FormatMDX is a .mdx file.
+	FormatMDX Format = "mdx"
+)
+
+// numberPrefix matches Docusaurus number prefixes such as "01-" or "2_".
+var numberPrefix = regexp.MustCompile(`^\d+[-_.\s]+`)
+
+// FormatOf returns the format for a file path, or "" if it is not a documentation file.
+func FormatOf(filePath string) Format {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".md":
+		return FormatMarkdown
+	case ".mdx":
+		return FormatMDX
+	default:
+		return ""
+	}
+}
+
+// DocID returns the Docusaurus doc ID for a file, following the default docs plugin:
+// the directory path plus the frontmatter id (or the file name without extension),
+// with number prefixes removed.
+func DocID(relPath, frontMatterID string) string {
+	dir, base := splitDocPath(relPath)
+	name := frontMatterID
+	if name == "" {
+		name = stripNumberPrefix(strings.TrimSuffix(base, path.Ext(base)))
+	}
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
+
+// DocSlug returns the URL path of a doc relative to the docs route.
+// An absolute frontmatter slug is used as-is and a relative one is resolved
+// against the file's directory. Without a slug, index pages (index, README,
+// or a file named after its directory) map to the directory itself.
+func DocSlug(relPath, frontMatterID, frontMatterSlug string) string {
+	dir, base := splitDocPath(relPath)
+	switch {
+	case strings.HasPrefix(frontMatterSlug, "/"):
+		return path.Clean(frontMatterSlug)
+	case frontMatterSlug != "":
+		return path.Join("/", dir, frontMatterSlug)
+	case isIndexFile(dir, base):
+		return path.Join("/", dir)
+	default:
+		return path.Join("/", DocID(relPath, frontMatterID))
+	}
+}
+
+// splitDocPath returns the slash-separated directory (with number prefixes
+// removed, "" for the root) and the file name.
+func splitDocPath(relPath string) (dir, base string) {
+	p := filepath.ToSlash(relPath)
+	dir, base = path.Split(p)
+	dir = strings.TrimSuffix(dir, "/")
+	if dir == "" {
+		return "", base
+	}
+	segments := strings.Split(dir, "/")
+	for i, s := range segments {
+		segments[i] = stripNumberPrefix(s)
+	}
+	return strings.Join(segments, "/"), base
+}
+
+// isIndexFile reports whether a file is its directory's index page.
+func isIndexFile(dir, base string) bool {
+	name := strings.ToLower(stripNumberPrefix(strings.TrimSuffix(base, path.Ext(base))))
+	return name == "index" || name == "readme" || (dir != "" && name == strings.ToLower(path.Base(dir)))
+}
+
+// stripNumberPrefix removes a number prefix unless the name is only a number.
+func stripNumberPrefix(name string) string {
+	stripped := numberPrefix.ReplaceAllString(name, "")
+	if stripped == "" {
+		return name
+	}
+	return stripped
+}
@@ -0,0 +1,175 @@
+package docs
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// DefaultDuplicateThreshold is the share of a paragraph's word sequences that
+// must appear in the linked doc for the paragraph to count as duplicated.
+const DefaultDuplicateThreshold = 0.8
+
+// minParagraphWords skips short paragraphs ("See [Segments](/segments).")
+// that are expected to repeat across docs.
+const minParagraphWords = 12
+
+// shingleSize is the number of consecutive words compared.
+const shingleSize = 3
+
+// Duplicate is a doc that repeats content from a doc it links to,
+// instead of only linking to it.
+type Duplicate struct {
+	Source   string   `json:"source"`   // Linking doc
+	Target   string   `json:"target"`   // Linked doc
+	Score    float64  `json:"score"`    // Share of the source's prose repeated in the target
+	Passages []string `json:"passages"` // Repeated source paragraphs (truncated)
+}
+
+// FindDuplicates compares each doc with the docs it links to and returns the
+// pairs where at least one paragraph of the linking doc repeats the linked
+// doc, most duplicated first. threshold is the share of a paragraph's word
+// sequences that must appear in the linked doc (0 uses DefaultDuplicateThreshold).
+func FindDuplicates(m *Manifest, g *LinkGraph, docsDir string, threshold float64) ([]Duplicate, error) {
+	if threshold <= 0 {
+		threshold = DefaultDuplicateThreshold
+	}
+
+	paragraphsByPath := make(map[string][]string)
+	load := func(path string) ([]string, error) {
+		if p, ok := paragraphsByPath[path]; ok {
+			return p, nil
+		}
+		content, err := ReadFile(filepath.Join(docsDir, path))
+		if err != nil {
+			return nil, err
+		}
+		p := proseParagraphs(content)
+		paragraphsByPath[path] = p
+		return p, nil
+	}
+
+	var duplicates []Duplicate
+	for _, f := range m.Files {
+		source, err := load(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		for _, targetPath := range g.Outbound[f.Path] {
+			target, err := load(targetPath)
+			if err != nil {
+				return nil, err
+			}
+			if d, ok := compareDocs(source, target, threshold); ok {
+				d.Source, d.Target = f.Path, targetPath
+				duplicates = append(duplicates, d)
+			}
+		}
+	}
+
+	slices.SortStableFunc(duplicates, func(a, b Duplicate) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return strings.Compare(a.Source+a.Target, b.Source+b.Target)
+	})
+	return duplicates, nil
+}
+
+// compareDocs returns the source paragraphs repeated in target.
+func compareDocs(source, target []string, threshold float64) (Duplicate, bool) {
+	targetShingles := make(map[string]bool)
+	for _, p := range target {
+		for _, s := range shingles(words(p)) {
+			targetShingles[s] = true
+		}
+	}
+
+	var d Duplicate
+	var totalWords, repeatedWords int
+	for _, p := range source {
+		w := words(p)
+		if len(w) < minParagraphWords {
+			continue
+		}
+		totalWords += len(w)
+
+		sh := shingles(w)
+		var found int
+		for _, s := range sh {
+			if targetShingles[s] {
+				found++
+			}
+		}
+		if float64(found)/float64(len(sh)) >= threshold {
+			repeatedWords += len(w)
+			d.Passages = append(d.Passages, truncateString(p, 160))
+		}
+	}
+	if len(d.Passages) == 0 {
+		return Duplicate{}, false
+	}
+	d.Score = float64(repeatedWords) / float64(totalWords)
+	return d, true
+}
+
+// shingles returns the overlapping shingleSize-word sequences of words.
+func shingles(words []string) []string {
+	if len(words) < shingleSize {
+		return nil
+	}
+	out := make([]string, 0, len(words)-shingleSize+1)
+	for i := 0; i+shingleSize <= len(words); i++ {
+		out = append(out, strings.Join(words[i:i+shingleSize], " "))
+	}
+	return out
+}
+
+// proseParagraphs returns a doc's prose paragraphs as plain text, skipping
+// frontmatter, headings, code blocks, imports and JSX/HTML blocks.
+func proseParagraphs(content string) []string {
+	lines := strings.Split(content, "\n")
+	var paragraphs []string
+	var current []string
+	flush := func() {
+		if len(current) > 0 {
+			paragraphs = append(paragraphs, plainText(strings.Join(current, " ")))
+			current = nil
+		}
+	}
+
+	fence := ""
+	for i := frontMatterEnd(lines); i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if f := openingFence(trimmed); f != "" {
+			flush()
+			fence = f
+			continue
+		}
+
+		switch {
+		case trimmed == "", strings.HasPrefix(trimmed, "#"), strings.HasPrefix(trimmed, "import "),
+			strings.HasPrefix(trimmed, "<"), strings.HasPrefix(trimmed, ":::"):
+			flush()
+		default:
+			current = append(current, trimmed)
+		}
+	}
+	flush()
+	return paragraphs
+}
+
+// String returns a one-line summary of the duplicate.
+func (d Duplicate) String() string {
+	return fmt.Sprintf("%s -> %s: %.0f%% of prose repeated (%d passages)", d.Source, d.Target, d.Score*100, len(d.Passages))
+}
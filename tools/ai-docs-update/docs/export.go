@@ -0,0 +1,164 @@
+package docs
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ExportFormat is a manifest export format.
+type ExportFormat string
+
+const (
+	ExportJSON ExportFormat = "json"
+	ExportYAML ExportFormat = "yaml"
+	ExportCSV  ExportFormat = "csv"
+)
+
+// ExportFormats lists the supported export formats.
+var ExportFormats = []ExportFormat{ExportJSON, ExportYAML, ExportCSV}
+
+// ErrUnknownExportFormat indicates an export format other than ExportFormats.
+var ErrUnknownExportFormat = errors.New("unknown export format")
+
+// csvHeader is the CSV column order. List columns are joined with "; ".
+var csvHeader = []string{
+	"schema_version", "path", "title", "description", "category", "audience", "content_type",
+	"platform", "sdk_kind", "id", "slug", "format", "tags", "sidebar_group", "parent", "children",
+	"position", "is_hub", "headings", "imports", "tab_groups", "admonitions", "links_to", "inbound_links",
+}
+
+// Export writes the manifest with all its metadata. JSON and YAML keep the
+// manifest's structure and field names; CSV has one row per doc, with the
+// schema version repeated in the first column and nested data flattened.
+func (m *Manifest) Export(w io.Writer, format ExportFormat) error {
+	switch format {
+	case ExportJSON:
+		data, err := json.MarshalIndent(m, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal manifest: %w", err)
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	case ExportYAML:
+		return m.exportYAML(w)
+	case ExportCSV:
+		return m.exportCSV(w)
+	}
+	return fmt.Errorf("%w: %q (expected one of %v)", ErrUnknownExportFormat, format, ExportFormats)
+}
+
+// exportYAML converts the JSON form to YAML, so both use the same field
+// names and order.
+func (m *Manifest) exportYAML(w io.Writer) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeOrdered(dec)
+	if err != nil {
+		return fmt.Errorf("failed to convert manifest: %w", err)
+	}
+	out, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// decodeOrdered decodes the next JSON value, keeping object key order in
+// yaml.MapSlice values.
+func decodeOrdered(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			obj := yaml.MapSlice{}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decodeOrdered(dec)
+				if err != nil {
+					return nil, err
+				}
+				obj = append(obj, yaml.MapItem{Key: key, Value: value})
+			}
+			_, err := dec.Token() // closing }
+			return obj, err
+		}
+		list := []any{}
+		for dec.More() {
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := dec.Token() // closing ]
+		return list, err
+	case json.Number:
+		if n, err := t.Int64(); err == nil {
+			return n, nil
+		}
+		return t.Float64()
+	}
+	return tok, nil // string, bool or nil
+}
+
+func (m *Manifest) exportCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, f := range m.Files {
+		var headings, imports, tabGroups, admonitions []string
+		for _, h := range f.Outline {
+			headings = append(headings, h.String())
+		}
+		if s := f.Structure; s != nil {
+			for _, imp := range s.Imports {
+				imports = append(imports, imp.Source)
+			}
+			for _, g := range s.TabGroups {
+				tabGroups = append(tabGroups, strings.Join(g.Labels, " | "))
+			}
+			for _, t := range slices.Sorted(maps.Keys(s.Admonitions)) {
+				admonitions = append(admonitions, fmt.Sprintf("%s=%d", t, s.Admonitions[t]))
+			}
+		}
+
+		row := []string{
+			strconv.Itoa(m.SchemaVersion), f.Path, f.Title, f.Description, f.Category, f.Audience, string(f.ContentType),
+			f.Platform, string(f.SDKKind), f.ID, f.Slug, string(f.Format), joinList(f.Tags), f.SidebarGroup, f.Parent, joinList(f.Children),
+			strconv.Itoa(f.Position), strconv.FormatBool(f.IsHub), joinList(headings), joinList(imports), joinList(tabGroups), joinList(admonitions),
+			joinList(f.LinksTo), strconv.Itoa(f.InboundLinks),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// joinList joins a CSV list column.
+func joinList(items []string) string {
+	return strings.Join(items, "; ")
+}
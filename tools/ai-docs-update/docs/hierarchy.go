@@ -0,0 +1,65 @@
+package docs
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/sidebar"
+)
+
+// ErrHubPage indicates an update targeting a hub page.
+var ErrHubPage = errors.New("hub pages cannot be update targets")
+
+// hubLabels are sidebar labels and titles used by section overview pages.
+var hubLabels = map[string]bool{
+	"overview":     true,
+	"introduction": true,
+}
+
+// ApplySidebars attaches each doc's sidebar group, parent, children and
+// position, and marks hub pages. Parents and children are given as manifest
+// paths; docs that are not in the manifest (e.g. excluded) are left out.
+// Docs that opted in with ai_hub: true stay hub pages.
+func ApplySidebars(m *Manifest, s *sidebar.Sidebars) {
+	pathByID := make(map[string]string, len(m.Files))
+	for _, f := range m.Files {
+		pathByID[f.ID] = f.Path
+	}
+
+	for i := range m.Files {
+		f := &m.Files[i]
+		entry, ok := s.Lookup(f.ID)
+		if !ok {
+			continue
+		}
+		f.SidebarGroup = entry.Group
+		f.Position = entry.Position
+		f.Parent = pathByID[entry.Parent]
+		f.Children = nil
+		for _, id := range entry.Children {
+			if p, ok := pathByID[id]; ok {
+				f.Children = append(f.Children, p)
+			}
+		}
+		f.IsHub = f.IsHub || isHub(f, entry)
+	}
+}
+
+// isHub reports whether a doc is a hub page: a section overview labelled
+// "Overview" or "Introduction". Hub pages mostly link to other docs and must
+// not receive feature details. Category landing pages are not hubs: many
+// (e.g. targeting.mdx) document their topic themselves, so the identify
+// prompt only steers feature details to their children.
+func isHub(f *DocFile, entry *sidebar.Entry) bool {
+	return hubLabels[strings.ToLower(entry.Label)] || hubLabels[strings.ToLower(f.Title)]
+}
+
+// FindFile returns the manifest entry for a path, or nil.
+func (m *Manifest) FindFile(path string) *DocFile {
+	for i := range m.Files {
+		if m.Files[i].Path == path {
+			return &m.Files[i]
+		}
+	}
+	return nil
+}
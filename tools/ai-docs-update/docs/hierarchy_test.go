@@ -0,0 +1,116 @@
+package docs
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/sidebar"
+)
+
+func TestApplySidebars(t *testing.T) {
+	docsDir := t.TempDir()
+	files := map[string]string{
+		"flags/overview.mdx":            "---\ntitle: Overview\n---\n\nWhat this section contains.\n",
+		"flags/intro.mdx":               "---\ntitle: Flags\n---\n\nStart here.\n",
+		"flags/targeting/targeting.mdx": "---\ntitle: Targeting\n---\n\n## Evaluation order\n\nRules are evaluated top to bottom.\n",
+		"flags/targeting/rules.mdx":     "---\ntitle: Custom rules\n---\n\nMatch users by attribute.\n",
+		"flags/quickstart/index.mdx":    "---\ntitle: Quickstart\n---\n\nCreate a flag in five minutes.\n",
+		"flags/quickstart/first.mdx":    "---\ntitle: First flag\n---\n\nCreate a flag.\n",
+		"flags/links.mdx":               "---\ntitle: Useful links\nai_hub: true\n---\n\n- [Rules](./targeting/rules.mdx)\n",
+		"flags/unlisted.mdx":            "---\ntitle: Unlisted\n---\n\nNot in a sidebar.\n",
+	}
+	for path, content := range files {
+		full := filepath.Join(docsDir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m, err := GenerateManifest(docsDir, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := sidebar.New(map[string]any{"docs": []any{
+		"flags/overview",
+		map[string]any{"type": "doc", "id": "flags/intro", "label": "Introduction"},
+		"flags/links",
+		map[string]any{
+			"type": "category", "label": "Targeting",
+			"link":  map[string]any{"type": "doc", "id": "flags/targeting/targeting"},
+			"items": []any{"flags/targeting/rules"},
+		},
+		map[string]any{
+			"type": "category", "label": "Quickstart",
+			"link":  map[string]any{"type": "doc", "id": "flags/quickstart/index"},
+			"items": []any{"flags/quickstart/first"},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ApplySidebars(m, s)
+
+	tests := []struct {
+		path     string
+		hub      bool
+		children []string
+	}{
+		{path: "flags/overview.mdx", hub: true},  // title
+		{path: "flags/intro.mdx", hub: true},     // sidebar label
+		{path: "flags/links.mdx", hub: true},     // ai_hub opt-in
+		{path: "flags/unlisted.mdx", hub: false}, // no sidebar entry
+		{path: "flags/targeting/targeting.mdx", hub: false, children: []string{"flags/targeting/rules.mdx"}}, // <dir>/<dir>.mdx landing page
+		{path: "flags/quickstart/index.mdx", hub: false, children: []string{"flags/quickstart/first.mdx"}},   // index landing page
+		{path: "flags/targeting/rules.mdx", hub: false},
+	}
+	for _, tt := range tests {
+		f := m.FindFile(filepath.FromSlash(tt.path))
+		if f == nil {
+			t.Errorf("%s: not in the manifest", tt.path)
+			continue
+		}
+		if f.IsHub != tt.hub || !slices.Equal(f.Children, tt.children) {
+			t.Errorf("%s: IsHub = %v, Children = %v; want %v, %v", tt.path, f.IsHub, f.Children, tt.hub, tt.children)
+		}
+	}
+}
+
+// TestApplySidebarsDocsTree pins hub detection on the real docs tree:
+// category landing pages with content of their own remain update targets,
+// while landing pages titled "Overview" do not.
+func TestApplySidebarsDocsTree(t *testing.T) {
+	m, err := GenerateManifest("../../../docs", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := sidebar.Load("../../../sidebars.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ApplySidebars(m, s)
+
+	tests := []struct {
+		path string
+		hub  bool
+	}{
+		{path: "feature-flags/creating-feature-flags/targeting/targeting.mdx", hub: false},
+		{path: "getting-started/quickstart/index.mdx", hub: true}, // titled "Overview"
+	}
+	for _, tt := range tests {
+		f := m.FindFile(filepath.FromSlash(tt.path))
+		if f == nil {
+			t.Errorf("%s: not in the manifest", tt.path)
+			continue
+		}
+		if f.IsHub != tt.hub {
+			t.Errorf("%s: IsHub = %v, want %v", tt.path, f.IsHub, tt.hub)
+		}
+		if len(f.Children) == 0 {
+			t.Errorf("%s: no children, want the category's docs", tt.path)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package docs
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// Link is an internal link or doc import found in a doc.
+type Link struct {
+	Target string `json:"target"`           // As written (e.g. "/feature-flags/segments#rules", "./_partial.mdx")
+	Line   int    `json:"line"`             // 1-based line in the file
+	Import bool   `json:"import,omitempty"` // MDX import of another doc
+}
+
+// BrokenLink is a link whose target does not exist.
+type BrokenLink struct {
+	Source string `json:"source"` // Path of the linking doc
+	Line   int    `json:"line"`
+	Target string `json:"target"`
+	Reason string `json:"reason"`
+}
+
+// LinkGraph is the directed graph of links between manifest docs.
+type LinkGraph struct {
+	Outbound map[string][]string `json:"outbound"` // Doc path -> paths it links to (sorted, no self links)
+	Inbound  map[string][]string `json:"inbound"`  // Doc path -> paths linking to it (sorted)
+	Broken   []BrokenLink        `json:"broken"`
+}
+
+var (
+	inlineLinkPattern = regexp.MustCompile(`(!?)\[[^\]]*\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+	refLinkPattern    = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:\s*<?([^\s>]+)`)
+	jsxLinkPattern    = regexp.MustCompile(`\b(?:href|to)=["']([^"']+)["']`)
+	importPattern     = regexp.MustCompile(`^(?:import\s.*?|\}\s*)from\s+['"]([^'"]+)['"]`)
+	inlineCodePattern = regexp.MustCompile("`[^`]*`")
+	schemePattern     = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:`)
+)
+
+// siteDocsPrefix is the Docusaurus alias for the docs directory in imports.
+const siteDocsPrefix = "@site/docs/"
+
+// ParseLinks returns the internal links and doc imports in a doc: Markdown
+// inline and reference links, JSX href/to attributes, and imports of .md/.mdx
+// files. External URLs, images, component imports and code are skipped.
+func ParseLinks(content string) []Link {
+	lines := strings.Split(content, "\n")
+	var links []Link
+	add := func(target string, line int, isImport bool) {
+		if target == "" || schemePattern.MatchString(target) || strings.HasPrefix(target, "//") {
+			return
+		}
+		links = append(links, Link{Target: target, Line: line, Import: isImport})
+	}
+
+	fence := ""
+	for i := frontMatterEnd(lines); i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if f := openingFence(trimmed); f != "" {
+			fence = f
+			continue
+		}
+
+		if m := importPattern.FindStringSubmatch(trimmed); m != nil {
+			if target := strings.TrimPrefix(m[1], siteDocsPrefix); FormatOf(target) != "" {
+				add(target, i+1, true)
+			}
+			continue
+		}
+
+		line := inlineCodePattern.ReplaceAllString(lines[i], "")
+		for _, m := range inlineLinkPattern.FindAllStringSubmatch(line, -1) {
+			if m[1] == "" { // not an image
+				add(m[2], i+1, false)
+			}
+		}
+		if m := refLinkPattern.FindStringSubmatch(line); m != nil {
+			add(m[1], i+1, false)
+		}
+		for _, m := range jsxLinkPattern.FindAllStringSubmatch(line, -1) {
+			add(m[1], i+1, false)
+		}
+	}
+	return links
+}
+
+// BuildLinkGraph resolves the links of every manifest doc. File links
+// ("../segments.mdx") resolve against the doc's directory; URL links resolve
+// against doc slugs, relative ones against the linking doc's URL. Targets
+// outside the manifest that exist under docsDir (e.g. excluded docs) are not
+// edges but not broken either. Anchors are checked against the target's outline.
+// Targets matching an ignore pattern (doublestar globs such as "/api/**" for
+// routes served by plugins) are skipped.
+func BuildLinkGraph(m *Manifest, docsDir string, ignore []string) *LinkGraph {
+	byPath := make(map[string]*DocFile, len(m.Files))
+	bySlug := make(map[string]*DocFile, len(m.Files))
+	for i := range m.Files {
+		f := &m.Files[i]
+		byPath[filepath.ToSlash(f.Path)] = f
+		if f.Slug != "" {
+			bySlug[f.Slug] = f
+		}
+	}
+
+	g := &LinkGraph{
+		Outbound: make(map[string][]string),
+		Inbound:  make(map[string][]string),
+	}
+	for i := range m.Files {
+		src := &m.Files[i]
+		for _, link := range src.Links {
+			if ignoredLink(link.Target, ignore) {
+				continue
+			}
+			target, reason := resolveLink(src, link, byPath, bySlug, docsDir)
+			if reason != "" {
+				g.Broken = append(g.Broken, BrokenLink{Source: src.Path, Line: link.Line, Target: link.Target, Reason: reason})
+				continue
+			}
+			if target == nil || target.Path == src.Path {
+				continue
+			}
+			if !slices.Contains(g.Outbound[src.Path], target.Path) {
+				g.Outbound[src.Path] = append(g.Outbound[src.Path], target.Path)
+				g.Inbound[target.Path] = append(g.Inbound[target.Path], src.Path)
+			}
+		}
+	}
+	for _, paths := range g.Outbound {
+		slices.Sort(paths)
+	}
+	for _, paths := range g.Inbound {
+		slices.Sort(paths)
+	}
+	return g
+}
+
+// resolveLink returns the manifest doc a link points to (nil for a target
+// outside the manifest), or the reason it is broken.
+func resolveLink(src *DocFile, link Link, byPath, bySlug map[string]*DocFile, docsDir string) (*DocFile, string) {
+	target, anchor, _ := strings.Cut(link.Target, "#")
+	target, _, _ = strings.Cut(target, "?")
+
+	var doc *DocFile
+	switch {
+	case target == "":
+		doc = src // same-page anchor
+	case FormatOf(target) != "":
+		p := path.Clean(path.Join(path.Dir(filepath.ToSlash(src.Path)), target))
+		if strings.HasPrefix(target, "/") || link.Import && !strings.HasPrefix(target, ".") {
+			p = path.Clean(strings.TrimPrefix(target, "/")) // relative to the docs directory
+		}
+		if doc = byPath[p]; doc == nil {
+			if fileExists(filepath.Join(docsDir, filepath.FromSlash(p))) {
+				return nil, ""
+			}
+			return nil, "file not found"
+		}
+	case path.Ext(target) != "":
+		return nil, "" // static asset (image, PDF, ...)
+	default:
+		slug := target
+		if !strings.HasPrefix(slug, "/") {
+			slug = path.Join(path.Dir(src.Slug), slug)
+		}
+		if slug = path.Clean(slug); slug != "/" {
+			slug = strings.TrimSuffix(slug, "/")
+		}
+		if doc = bySlug[slug]; doc == nil {
+			if slugFileExists(docsDir, slug) {
+				return nil, ""
+			}
+			return nil, "page not found"
+		}
+	}
+
+	if anchor != "" && len(doc.Outline) > 0 && !slices.ContainsFunc(doc.Outline, func(h Heading) bool { return h.Anchor == anchor }) {
+		return nil, "anchor #" + anchor + " not found"
+	}
+	return doc, ""
+}
+
+// ignoredLink reports whether target (without its anchor) matches an ignore pattern.
+func ignoredLink(target string, ignore []string) bool {
+	target, _, _ = strings.Cut(target, "#")
+	for _, pattern := range ignore {
+		if ok, _ := doublestar.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// slugFileExists reports whether a doc file for a default slug exists under
+// docsDir (e.g. an excluded doc that is not in the manifest).
+func slugFileExists(docsDir, slug string) bool {
+	base := filepath.Join(docsDir, filepath.FromSlash(strings.TrimPrefix(slug, "/")))
+	for _, candidate := range []string{base + ".md", base + ".mdx", filepath.Join(base, "index.md"), filepath.Join(base, "index.mdx")} {
+		if fileExists(candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// fileExists reports whether path is an existing regular file.
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// ApplyLinkGraph attaches each doc's outbound links and inbound link count.
+func ApplyLinkGraph(m *Manifest, g *LinkGraph) {
+	for i := range m.Files {
+		f := &m.Files[i]
+		f.LinksTo = g.Outbound[f.Path]
+		f.InboundLinks = len(g.Inbound[f.Path])
+	}
+}
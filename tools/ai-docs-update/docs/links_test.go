@@ -0,0 +1,195 @@
+package docs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestParseLinks(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []Link
+	}{
+		{
+			name:    "inline links",
+			content: "See [segments](/feature-flags/segments#rules) and [the SDK](<../sdk/android.mdx> \"Android\").",
+			want: []Link{
+				{Target: "/feature-flags/segments#rules", Line: 1},
+				{Target: "../sdk/android.mdx", Line: 1},
+			},
+		},
+		{
+			name:    "reference links",
+			content: "Read [the guide][guide].\n\n[guide]: ./getting-started.md\n   [other]: <#setup>",
+			want: []Link{
+				{Target: "./getting-started.md", Line: 3},
+				{Target: "#setup", Line: 4},
+			},
+		},
+		{
+			name:    "jsx attributes",
+			content: `<Link to="/integration/overview">Integrations</Link> <a href='../audit-logs'>Logs</a>`,
+			want: []Link{
+				{Target: "/integration/overview", Line: 1},
+				{Target: "../audit-logs", Line: 1},
+			},
+		},
+		{
+			name: "doc imports",
+			content: "import Partial from './_partial.mdx';\n" +
+				"import Shared from '@site/docs/shared/_intro.md';\n" +
+				"import {\n  Foo,\n} from '../_components.mdx';\n" +
+				"import Tabs from '@theme/Tabs';\n" +
+				"import styles from './styles.module.css';",
+			want: []Link{
+				{Target: "./_partial.mdx", Line: 1, Import: true},
+				{Target: "shared/_intro.md", Line: 2, Import: true},
+				{Target: "../_components.mdx", Line: 5, Import: true},
+			},
+		},
+		{
+			name: "external links and images",
+			content: "[GitHub](https://github.com/bucketeer-io) [mail](mailto:team@example.com) [cdn](//cdn.example.com/x)\n" +
+				"![Diagram](./img/diagram.png) [empty]()",
+		},
+		{
+			name: "code is skipped",
+			content: "Use `[not a link](/code)` here.\n" +
+				"```md\n[fenced](/fenced)\n```\n" +
+				"~~~~\n```\n[still fenced](/fenced)\n~~~~\n" +
+				"[after](/after)",
+			want: []Link{{Target: "/after", Line: 9}},
+		},
+		{
+			name:    "frontmatter is skipped",
+			content: "---\ntitle: Links\ndescription: \"[x](/front)\"\n---\n\n[body](/body)",
+			want:    []Link{{Target: "/body", Line: 6}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseLinks(tt.content); !slices.Equal(got, tt.want) {
+				t.Errorf("ParseLinks() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildLinkGraph(t *testing.T) {
+	docsDir := t.TempDir()
+	for _, p := range []string{"excluded/draft.mdx", "legacy/index.md"} {
+		full := filepath.Join(docsDir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("# Draft\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	links := func(targets ...string) []Link {
+		var out []Link
+		for i, target := range targets {
+			out = append(out, Link{Target: target, Line: i + 1})
+		}
+		return out
+	}
+	m := &Manifest{Files: []DocFile{
+		{
+			Path: "feature-flags/segments.mdx", Slug: "/feature-flags/segments",
+			Outline: []Heading{{Level: 2, Text: "Rules", Anchor: "rules"}},
+			Links: links(
+				"./create-flag.mdx",       // file link, relative to the doc
+				"#rules",                  // same-page anchor: no self edge
+				"/sdk/android/index.mdx",  // file link, relative to the docs directory
+				"create-flag",             // URL link, relative to the doc's URL
+				"../excluded/draft.mdx",   // excluded doc: not an edge, not broken
+				"/legacy",                 // excluded index doc by slug
+				"/img/flags.png",          // static asset
+				"/api/flags/list",         // ignored
+				"./missing.mdx",           // broken file link
+				"/feature-flags/missing/", // broken URL link
+				"#nope",                   // broken anchor
+			),
+		},
+		{
+			Path: "feature-flags/create-flag.mdx", Slug: "/feature-flags/create-flag",
+			Outline: []Heading{{Level: 2, Text: "Variations", Anchor: "variations"}},
+			Links:   links("/feature-flags/segments#rules", "../sdk/android/?tab=kotlin#setup", "segments#missing"),
+		},
+		{
+			Path: "sdk/android/index.mdx", Slug: "/sdk/android",
+			Links: []Link{{Target: "../../feature-flags/_partial.mdx", Line: 1, Import: true}, {Target: "feature-flags/segments.mdx", Line: 2, Import: true}},
+		},
+		{Path: "feature-flags/_partial.mdx"},
+	}}
+
+	g := BuildLinkGraph(m, docsDir, []string{"/api/**"})
+
+	wantOutbound := map[string][]string{
+		"feature-flags/segments.mdx":    {"feature-flags/create-flag.mdx", "sdk/android/index.mdx"},
+		"feature-flags/create-flag.mdx": {"feature-flags/segments.mdx", "sdk/android/index.mdx"},
+		"sdk/android/index.mdx":         {"feature-flags/_partial.mdx", "feature-flags/segments.mdx"},
+	}
+	if !reflect.DeepEqual(g.Outbound, wantOutbound) {
+		t.Errorf("Outbound =\n%v\nwant\n%v", g.Outbound, wantOutbound)
+	}
+	wantInbound := map[string][]string{
+		"feature-flags/create-flag.mdx": {"feature-flags/segments.mdx"},
+		"feature-flags/segments.mdx":    {"feature-flags/create-flag.mdx", "sdk/android/index.mdx"},
+		"feature-flags/_partial.mdx":    {"sdk/android/index.mdx"},
+		"sdk/android/index.mdx":         {"feature-flags/create-flag.mdx", "feature-flags/segments.mdx"},
+	}
+	if !reflect.DeepEqual(g.Inbound, wantInbound) {
+		t.Errorf("Inbound =\n%v\nwant\n%v", g.Inbound, wantInbound)
+	}
+	wantBroken := []BrokenLink{
+		{Source: "feature-flags/segments.mdx", Line: 9, Target: "./missing.mdx", Reason: "file not found"},
+		{Source: "feature-flags/segments.mdx", Line: 10, Target: "/feature-flags/missing/", Reason: "page not found"},
+		{Source: "feature-flags/segments.mdx", Line: 11, Target: "#nope", Reason: "anchor #nope not found"},
+		{Source: "feature-flags/create-flag.mdx", Line: 3, Target: "segments#missing", Reason: "anchor #missing not found"},
+	}
+	if !slices.Equal(g.Broken, wantBroken) {
+		t.Errorf("Broken =\n%v\nwant\n%v", g.Broken, wantBroken)
+	}
+
+	ApplyLinkGraph(m, g)
+	for _, tt := range []struct {
+		path    string
+		linksTo []string
+		inbound int
+	}{
+		{path: "feature-flags/segments.mdx", linksTo: wantOutbound["feature-flags/segments.mdx"], inbound: 2},
+		{path: "sdk/android/index.mdx", linksTo: wantOutbound["sdk/android/index.mdx"], inbound: 2},
+		{path: "feature-flags/_partial.mdx", inbound: 1},
+	} {
+		f := m.FindFile(tt.path)
+		if !slices.Equal(f.LinksTo, tt.linksTo) || f.InboundLinks != tt.inbound {
+			t.Errorf("%s: LinksTo = %v, InboundLinks = %d; want %v, %d", tt.path, f.LinksTo, f.InboundLinks, tt.linksTo, tt.inbound)
+		}
+	}
+}
+
+func TestIgnoredLink(t *testing.T) {
+	ignore := []string{"/api/**", "/changelog"}
+	tests := []struct {
+		target string
+		want   bool
+	}{
+		{target: "/api/flags/list", want: true},
+		{target: "/api/flags#get", want: true},
+		{target: "/changelog#v1", want: true},
+		{target: "/changelog/v1", want: false},
+		{target: "/feature-flags/api", want: false},
+		{target: "../api/flags", want: false},
+	}
+	for _, tt := range tests {
+		if got := ignoredLink(tt.target, ignore); got != tt.want {
+			t.Errorf("ignoredLink(%q) = %v, want %v", tt.target, got, tt.want)
+		}
+	}
+}
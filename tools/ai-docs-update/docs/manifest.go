@@ -3,6 +3,8 @@ package docs
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,9 +13,15 @@ import (
 	"github.com/adrg/frontmatter"
 )
 
+// ManifestSchemaVersion is the version of the manifest's serialized form.
+// It is bumped when DocFile fields are renamed, removed or change meaning,
+// so tools consuming exported manifests can detect incompatible changes.
+const ManifestSchemaVersion = 1
+
 // Manifest holds a list of all documentation files.
 type Manifest struct {
-	Files []DocFile `json:"files"`
+	SchemaVersion int       `json:"schema_version"` // 0 for manifests saved before versioning
+	Files         []DocFile `json:"files"`
 }
 
 // DefaultExcludeDirs lists directories excluded from manifest generation by default.
@@ -43,28 +51,114 @@ const (
 
 // DocFile represents a single documentation file.
 type DocFile struct {
-	Path        string      `json:"path"`         // Relative path from docs root (e.g., "feature-flags/segments.mdx")
-	Title       string      `json:"title"`        // Title from frontmatter
-	Description string      `json:"description"`  // First paragraph of content
-	Tags        []string    `json:"tags"`         // Tags from frontmatter for categorization
-	Category    string      `json:"category"`     // Inferred category (sdk, feature-flags, etc.)
-	Audience    string      `json:"audience"`     // Inferred audience (external-developers, operators, admins)
-	ContentType ContentType `json:"content_type"` // What type of content belongs here
+	Path        string      `json:"path"`               // Relative path from docs root (e.g., "feature-flags/segments.mdx")
+	Title       string      `json:"title"`              // Title from frontmatter
+	Description string      `json:"description"`        // First paragraph of content
+	Tags        []string    `json:"tags"`               // Tags from frontmatter for categorization
+	Category    string      `json:"category"`           // Inferred category (sdk, feature-flags, etc.)
+	Audience    string      `json:"audience"`           // Inferred audience (external-developers, operators, admins)
+	ContentType ContentType `json:"content_type"`       // What type of content belongs here
+	Platform    string      `json:"platform,omitempty"` // SDK platform (e.g. "android"); empty for non-SDK docs
+	SDKKind     SDKKind     `json:"sdk_kind,omitempty"` // Native SDK or OpenFeature provider docs
+	ID          string      `json:"id"`                 // Docusaurus doc ID (e.g., "feature-flags/segments")
+	Slug        string      `json:"slug"`               // URL path (e.g., "/feature-flags/segments")
+	Format      Format      `json:"format"`             // Source format from the extension (md or mdx)
+
+	// Sidebar hierarchy (see ApplySidebars); empty for docs not in a sidebar
+	SidebarGroup string   `json:"sidebar_group,omitempty"` // Section and category labels (e.g., "feature flags > Targeting")
+	Parent       string   `json:"parent,omitempty"`        // Path of the category landing page above this doc
+	Children     []string `json:"children,omitempty"`      // Paths of the docs in the category this doc lands
+	Position     int      `json:"position,omitempty"`      // 1-based order in the sidebar
+	IsHub        bool     `json:"is_hub"`                  // Overview page or ai_hub: true; never an update target
+
+	// Heading tree in document order (see ParseOutline)
+	Outline []Heading `json:"outline,omitempty"`
+
+	// Imports, tab groups and admonitions (see ParseStructure); nil if there are none
+	Structure *Structure `json:"structure,omitempty"`
+
+	// Internal links as written (see ParseLinks) and the resolved link graph (see ApplyLinkGraph)
+	Links        []Link   `json:"links,omitempty"`
+	LinksTo      []string `json:"links_to,omitempty"` // Paths of the manifest docs this doc links to
+	InboundLinks int      `json:"inbound_links"`      // Number of manifest docs linking to this doc
 }
 
 // FrontMatter represents the frontmatter of a documentation file.
 type FrontMatter struct {
+	ID          string   `yaml:"id"`
 	Title       string   `yaml:"title"`
 	Description string   `yaml:"description"`
 	Slug        string   `yaml:"slug"`
 	Sidebar     string   `yaml:"sidebar_label"`
 	Tags        []string `yaml:"tags"`
+
+	// Classification overrides (see Rules.Classify)
+	AIContentType string `yaml:"ai_content_type"`
+	AIAudience    string `yaml:"ai_audience"`
+	AIExclude     bool   `yaml:"ai_exclude"`
+	AIHub         bool   `yaml:"ai_hub"` // Never an update target (see DocFile.IsHub)
+}
+
+// ManifestOption configures GenerateManifest.
+type ManifestOption func(*manifestOptions)
+
+type manifestOptions struct {
+	rules *Rules
+	cache *ManifestCache
+}
+
+// WithRules sets the classification rules (default: DefaultRules).
+func WithRules(rules *Rules) ManifestOption {
+	return func(o *manifestOptions) {
+		if rules != nil {
+			o.rules = rules
+		}
+	}
+}
+
+// LoadManifest reads a manifest previously written as JSON (e.g. by the manifest command).
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	if m.SchemaVersion > ManifestSchemaVersion {
+		return nil, fmt.Errorf("manifest %s has schema version %d; this version supports up to %d", path, m.SchemaVersion, ManifestSchemaVersion)
+	}
+	return &m, nil
 }
 
-// GenerateManifest scans the docs directory and generates a manifest of all .mdx files.
+// WithCache reuses unchanged files' parse results from cache and updates it
+// with the files parsed in this run. Save the cache afterwards to keep them.
+func WithCache(cache *ManifestCache) ManifestOption {
+	return func(o *manifestOptions) {
+		o.cache = cache
+	}
+}
+
+// GenerateManifest scans the docs directory and generates a manifest of all .md and .mdx files.
 // Directories in excludeDirs are skipped. If excludeDirs is nil, DefaultExcludeDirs is used.
 // Files in excludeFiles are skipped. If excludeFiles is nil, DefaultExcludeFiles is used.
-func GenerateManifest(docsDir string, excludeDirs []string, excludeFiles []string) (*Manifest, error) {
+// Docs with ai_exclude: true in their frontmatter are skipped as well.
+func GenerateManifest(docsDir string, excludeDirs []string, excludeFiles []string, opts ...ManifestOption) (*Manifest, error) {
+	o := manifestOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.rules == nil {
+		o.rules = DefaultRules()
+	}
+	if o.cache == nil {
+		o.cache = NewManifestCache() // parse everything, discard afterwards
+	}
+	o.cache.useRules(o.rules)
+	o.cache.reused, o.cache.parsed = 0, 0
+	seen := make(map[string]bool)
+
 	if excludeDirs == nil {
 		excludeDirs = DefaultExcludeDirs
 	}
@@ -95,8 +189,8 @@ func GenerateManifest(docsDir string, excludeDirs []string, excludeFiles []strin
 			return nil
 		}
 
-		// Only process .mdx files
-		if !strings.HasSuffix(path, ".mdx") {
+		// Only process documentation files (.md and .mdx)
+		if FormatOf(path) == "" {
 			return nil
 		}
 
@@ -105,12 +199,24 @@ func GenerateManifest(docsDir string, excludeDirs []string, excludeFiles []strin
 			return nil
 		}
 
-		docFile, err := parseDocFile(docsDir, path)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to read %s: %v\n", path, err)
+			return nil
+		}
+		seen[relPath] = true
+
+		docFile, err := o.cache.parse(relPath, data, func() (*DocFile, error) {
+			return parseDocFile(docsDir, path, data, o.rules)
+		})
 		if err != nil {
 			// Log warning but continue processing other files
 			fmt.Fprintf(os.Stderr, "Warning: failed to parse %s: %v\n", path, err)
 			return nil
 		}
+		if docFile == nil {
+			return nil // ai_exclude
+		}
 
 		files = append(files, *docFile)
 		return nil
@@ -119,21 +225,42 @@ func GenerateManifest(docsDir string, excludeDirs []string, excludeFiles []strin
 	if err != nil {
 		return nil, fmt.Errorf("failed to walk docs directory: %w", err)
 	}
+	o.cache.prune(seen)
 
-	return &Manifest{Files: files}, nil
+	return &Manifest{SchemaVersion: ManifestSchemaVersion, Files: files}, nil
 }
 
-// parseDocFile parses a single documentation file and extracts metadata.
-func parseDocFile(docsDir, filePath string) (*DocFile, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+// ExcludedBy returns the exclude_dirs or exclude_files setting that keeps
+// relPath out of the manifest, or "" if it is not excluded. Nil lists use
+// the defaults, as in GenerateManifest.
+func ExcludedBy(relPath string, excludeDirs []string, excludeFiles []string) string {
+	if excludeDirs == nil {
+		excludeDirs = DefaultExcludeDirs
+	}
+	if excludeFiles == nil {
+		excludeFiles = DefaultExcludeFiles
+	}
+
+	topDir := strings.Split(relPath, string(filepath.Separator))[0]
+	if topDir != relPath && toSet(excludeDirs)[topDir] {
+		return "exclude_dirs: " + topDir
 	}
-	defer file.Close()
+	excludeFileSet := toSet(excludeFiles)
+	if excludeFileSet[relPath] {
+		return "exclude_files: " + relPath
+	}
+	if base := filepath.Base(relPath); excludeFileSet[base] {
+		return "exclude_files: " + base
+	}
+	return ""
+}
 
+// parseDocFile parses a single documentation file's content and extracts metadata.
+// It returns nil if the doc excludes itself with ai_exclude.
+func parseDocFile(docsDir, filePath string, data []byte, rules *Rules) (*DocFile, error) {
 	// Parse frontmatter
 	var fm FrontMatter
-	content, err := frontmatter.Parse(file, &fm)
+	content, err := frontmatter.Parse(bytes.NewReader(data), &fm)
 	if err != nil {
 		// If frontmatter parsing fails, try to extract basic info
 		fm.Title = extractTitleFromFilename(filePath)
@@ -154,17 +281,34 @@ func parseDocFile(docsDir, filePath string) (*DocFile, error) {
 		description = extractFirstParagraph(string(content))
 	}
 
-	// Infer category, audience, and content type from path
-	category, audience, contentType := inferDocMetadata(relPath)
+	// Classify by path rules and frontmatter overrides
+	class := rules.Classify(relPath, fm)
+	if class.Exclude {
+		return nil, nil
+	}
+
+	var structure *Structure
+	if s := ParseStructure(string(data)); !s.IsEmpty() {
+		structure = &s
+	}
 
 	return &DocFile{
 		Path:        relPath,
 		Title:       title,
 		Description: truncateString(description, 200),
 		Tags:        fm.Tags,
-		Category:    category,
-		Audience:    audience,
-		ContentType: contentType,
+		Category:    class.Category,
+		Audience:    class.Audience,
+		ContentType: class.ContentType,
+		Platform:    class.Platform,
+		SDKKind:     class.SDKKind,
+		ID:          DocID(relPath, fm.ID),
+		Slug:        DocSlug(relPath, fm.ID, fm.Slug),
+		Format:      FormatOf(relPath),
+		Outline:     ParseOutline(string(data)),
+		Links:       ParseLinks(string(data)),
+		Structure:   structure,
+		IsHub:       fm.AIHub,
 	}, nil
 }
 
@@ -186,25 +330,57 @@ func extractTitleFromFilename(path string) string {
 	return strings.Join(words, " ")
 }
 
-// extractFirstParagraph extracts the first non-empty paragraph from content.
+// extractFirstParagraph extracts the first non-empty paragraph from content,
+// skipping headings, imports, JSX tags, and the contents of code blocks and
+// admonitions.
 func extractFirstParagraph(content string) string {
 	scanner := bufio.NewScanner(strings.NewReader(content))
 	var paragraph strings.Builder
 	inParagraph := false
+	fence := ""
+	admonitionDepth := 0
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 
+		// Skip code blocks and admonitions (notes and tips are not the page's summary)
+		if fence != "" {
+			if strings.HasPrefix(line, fence) && strings.Trim(line, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if f := openingFence(line); f != "" {
+			if inParagraph {
+				break
+			}
+			fence = f
+			continue
+		}
+		if m := admonitionPattern.FindStringSubmatch(line); m != nil {
+			if inParagraph {
+				break
+			}
+			if m[1] != "" {
+				admonitionDepth++
+			} else if admonitionDepth > 0 {
+				admonitionDepth--
+			}
+			continue
+		}
+		if admonitionDepth > 0 {
+			continue
+		}
+
 		// Skip empty lines at the beginning
 		if !inParagraph && line == "" {
 			continue
 		}
 
-		// Skip headers, code blocks, and import statements
+		// Skip headers, import statements and JSX tags
 		if strings.HasPrefix(line, "#") ||
-			strings.HasPrefix(line, "```") ||
 			strings.HasPrefix(line, "import ") ||
-			strings.HasPrefix(line, ":::") {
+			strings.HasPrefix(line, "<") {
 			if inParagraph {
 				break
 			}
@@ -262,49 +438,3 @@ func ReadFile(path string) (string, error) {
 	}
 	return string(data), nil
 }
-
-// inferDocMetadata derives category, audience, and content type from path.
-// Based on actual docs structure analysis.
-//
-// ContentType determines what kind of content is appropriate:
-//   - user-guide: User-facing behavior (NO implementation details, code internals)
-//   - admin-config: Dashboard UI administration (NO CLI flags, Helm values, env vars)
-//   - developer-reference: SDK/API reference (public methods, integration code)
-func inferDocMetadata(path string) (category, audience string, contentType ContentType) {
-	switch {
-	// External developers - SDK integration (developer-reference)
-	case strings.HasPrefix(path, "sdk/"):
-		return "sdk", "external-developers", ContentTypeDeveloperRef
-	case strings.HasPrefix(path, "integration/"):
-		return "integration", "external-developers", ContentTypeDeveloperRef
-
-	// New users - onboarding (user-guide)
-	case strings.HasPrefix(path, "getting-started/"):
-		return "getting-started", "new-users", ContentTypeUserGuide
-
-	// Operators/Product teams - feature management (user-guide)
-	case strings.HasPrefix(path, "feature-flags/"):
-		return "feature-flags", "operators", ContentTypeUserGuide
-	case strings.HasPrefix(path, "experimentation/"):
-		return "experimentation", "operators", ContentTypeUserGuide
-
-	// Admins - organization management (admin-config)
-	case strings.HasPrefix(path, "organization-settings/"):
-		return "organization-settings", "admins", ContentTypeAdminConfig
-
-	// All users - changelog (user-guide)
-	case strings.HasPrefix(path, "changelog/"):
-		return "changelog", "all", ContentTypeUserGuide
-
-	// Engineering leads - best practices (user-guide)
-	case strings.HasPrefix(path, "best-practices/"):
-		return "best-practices", "engineering-leads", ContentTypeUserGuide
-
-	// Contributors - contribution guide (user-guide)
-	case strings.HasPrefix(path, "contribution-guide/"):
-		return "contribution-guide", "contributors", ContentTypeUserGuide
-
-	default:
-		return "general", "all", ContentTypeUserGuide
-	}
-}
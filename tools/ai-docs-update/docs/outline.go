@@ -0,0 +1,311 @@
+package docs
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrTargetNotFound indicates a target location naming a heading the doc does not have.
+var ErrTargetNotFound = errors.New("target heading not found in document")
+
+// Heading is a section heading in a doc.
+type Heading struct {
+	Level     int    `json:"level"`      // 1-6
+	Text      string `json:"text"`       // Heading text without markdown formatting
+	Anchor    string `json:"anchor"`     // Docusaurus anchor (e.g. "inviting-new-members")
+	StartLine int    `json:"start_line"` // 1-based line of the heading in the file
+	EndLine   int    `json:"end_line"`   // Last line of the section, including subsections
+}
+
+// String returns the heading in markdown form (e.g. "## Inviting New Members").
+func (h Heading) String() string {
+	return strings.Repeat("#", h.Level) + " " + h.Text
+}
+
+var (
+	atxHeadingPattern  = regexp.MustCompile(`^ {0,3}(#{1,6})[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+	explicitIDPattern  = regexp.MustCompile(`\s*\{#([^}\s]+)\}$`)
+	mdLinkPattern      = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	htmlTagPattern     = regexp.MustCompile(`</?[A-Za-z][^>]*>`)
+	headingRefPattern  = regexp.MustCompile(`#{1,6}[ \t]+((?:[^'"‘’“”,;\n]|\b['’]\b)+)`)
+	quotedRefPattern   = regexp.MustCompile(`['"‘“]((?:[^'"‘’“”\n]|\b['’]\b)+)['"’”]\s+(?:section|heading)`)
+	emphasisReplacer   = strings.NewReplacer("`", "", "**", "", "__", "", "*", "")
+	trailingRefPattern = regexp.MustCompile(`(?i)\s+(?:section|heading)$`)
+)
+
+// ParseOutline returns the ATX headings (# to ######) of a doc in order.
+// Frontmatter and fenced code blocks are skipped; line numbers count from the
+// start of the file. Anchors follow Docusaurus: an explicit {#id} wins,
+// otherwise the GitHub-style slug of the text, numbered when repeated.
+func ParseOutline(content string) []Heading {
+	lines := strings.Split(content, "\n")
+	start := frontMatterEnd(lines)
+
+	var headings []Heading
+	seen := make(map[string]int)
+	fence := ""
+	for i := start; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+
+		// Skip fenced code blocks (``` or ~~~, closed by a fence at least as long)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if f := openingFence(trimmed); f != "" {
+			fence = f
+			continue
+		}
+
+		m := atxHeadingPattern.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		text := m[2]
+		var anchor string
+		if id := explicitIDPattern.FindStringSubmatch(text); id != nil {
+			anchor = id[1]
+			text = strings.TrimSpace(text[:len(text)-len(id[0])])
+		}
+		text = plainText(text)
+		if anchor == "" {
+			anchor = uniqueSlug(Slugify(text), seen)
+		}
+
+		headings = append(headings, Heading{
+			Level:     len(m[1]),
+			Text:      text,
+			Anchor:    anchor,
+			StartLine: i + 1,
+		})
+	}
+
+	// A section ends before the next heading of the same or a higher level
+	lastLine := len(lines)
+	if lastLine > 0 && lines[lastLine-1] == "" {
+		lastLine--
+	}
+	for i := range headings {
+		headings[i].EndLine = lastLine
+		for _, next := range headings[i+1:] {
+			if next.Level <= headings[i].Level {
+				headings[i].EndLine = next.StartLine - 1
+				break
+			}
+		}
+	}
+	return headings
+}
+
+// frontMatterEnd returns the index of the first line after the frontmatter, or 0.
+func frontMatterEnd(lines []string) int {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return 0
+	}
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// openingFence returns the fence marker if line opens a fenced code block.
+func openingFence(line string) string {
+	for _, c := range []string{"`", "~"} {
+		n := len(line) - len(strings.TrimLeft(line, c))
+		if n >= 3 {
+			return strings.Repeat(c, n)
+		}
+	}
+	return ""
+}
+
+// plainText strips inline markdown and HTML/JSX tags from heading text.
+func plainText(s string) string {
+	s = mdLinkPattern.ReplaceAllString(s, "$1")
+	s = htmlTagPattern.ReplaceAllString(s, "")
+	s = emphasisReplacer.Replace(s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Slugify returns the GitHub-style anchor slug Docusaurus uses for heading text:
+// lowercased, punctuation removed and spaces replaced by hyphens.
+func Slugify(text string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsNumber(r), unicode.Is(unicode.Mn, r), r == '-', r == '_':
+			sb.WriteRune(r)
+		case r == ' ':
+			sb.WriteRune('-')
+		}
+	}
+	return sb.String()
+}
+
+// uniqueSlug numbers repeated slugs the way Docusaurus does (foo, foo-1, foo-2).
+func uniqueSlug(slug string, seen map[string]int) string {
+	n, ok := seen[slug]
+	seen[slug] = n + 1
+	if !ok {
+		return slug
+	}
+	return fmt.Sprintf("%s-%d", slug, n)
+}
+
+// HeadingRefs returns the headings a target location refers to, e.g.
+// "Inviting New Members" for "In '## Inviting New Members' section, after step 4".
+// Markdown heading markers ("## ...") and quoted names followed by "section"
+// or "heading" are recognized. Headings of the doc are looked for first,
+// longest first, so that heading text containing quotes or commas ("What the
+// AI Assistant can and can't do") is taken whole; other references end at
+// a comma, a semicolon or a quote that is not an apostrophe within a word.
+func (f *DocFile) HeadingRefs(target string) []string {
+	var refs []string
+	add := func(ref string) {
+		ref = plainText(trailingRefPattern.ReplaceAllString(strings.TrimSpace(ref), ""))
+		if ref != "" && !containsFold(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+
+	headings := slices.SortedStableFunc(slices.Values(f.Outline), func(a, b Heading) int {
+		return len(b.Text) - len(a.Text)
+	})
+	for _, h := range headings {
+		var found bool
+		if found, target = maskHeadingRef(target, h.Text); found {
+			add(h.Text)
+		}
+	}
+
+	for _, m := range headingRefPattern.FindAllStringSubmatch(target, -1) {
+		add(m[1])
+	}
+	for _, m := range quotedRefPattern.FindAllStringSubmatch(target, -1) {
+		add(strings.TrimLeft(m[1], "# "))
+	}
+	return refs
+}
+
+// maskHeadingRef looks for references to the heading text in target (after a
+// heading marker or an opening quote, ending at a word boundary) and blanks
+// them out, marker and closing quote included, so the reference patterns do
+// not split them again.
+func maskHeadingRef(target, text string) (bool, string) {
+	if text == "" {
+		return false, target
+	}
+	pattern := regexp.MustCompile(`(?i)(?:#{1,6}[ \t]+|['"‘“]#{0,6}[ \t]*)(` + regexp.QuoteMeta(text) + `)`)
+	var found bool
+	for _, m := range pattern.FindAllStringSubmatchIndex(target, -1) {
+		start, end := m[0], m[3]
+		if r, _ := utf8.DecodeRuneInString(target[end:]); unicode.IsLetter(r) || unicode.IsNumber(r) {
+			continue
+		}
+		if r, size := utf8.DecodeRuneInString(target[end:]); strings.ContainsRune(`'"’”`, r) {
+			end += size
+		}
+		target = target[:start] + strings.Repeat(" ", end-start) + target[end:]
+		found = true
+	}
+	return found, target
+}
+
+// FindHeading returns the heading best matching ref and whether the match is
+// exact (same text or anchor, or ref starting with the heading text as an
+// unquoted reference running on into the sentence does). Close matches
+// (mostly the same words) are returned as inexact; nil means no heading matches.
+func (f *DocFile) FindHeading(ref string) (*Heading, bool) {
+	slug := Slugify(ref)
+	var prefix *Heading
+	for i := range f.Outline {
+		h := &f.Outline[i]
+		if strings.EqualFold(h.Text, ref) || h.Anchor == slug {
+			return h, true
+		}
+		if hasWordPrefix(ref, h.Text) && (prefix == nil || len(h.Text) > len(prefix.Text)) {
+			prefix = h
+		}
+	}
+	if prefix != nil {
+		return prefix, true
+	}
+
+	var best *Heading
+	var bestScore float64
+	refWords := words(ref)
+	for i := range f.Outline {
+		h := &f.Outline[i]
+		if score := similarity(refWords, words(h.Text)); score > bestScore {
+			best, bestScore = h, score
+		}
+	}
+	if bestScore < minHeadingSimilarity {
+		return nil, false
+	}
+	return best, false
+}
+
+// minHeadingSimilarity is the word overlap needed to correct a heading reference.
+const minHeadingSimilarity = 0.6
+
+// words returns the lowercased, crudely stemmed words of s,
+// so that e.g. "Invite" and "Inviting" compare equal.
+func words(s string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for i, w := range fields {
+		for _, suffix := range []string{"ing", "ed", "es", "s", "e"} {
+			if stem := strings.TrimSuffix(w, suffix); stem != w && len(stem) >= 3 {
+				fields[i] = stem
+				break
+			}
+		}
+	}
+	return fields
+}
+
+// similarity returns the Jaccard similarity of two word lists.
+func similarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	setA := toSet(a)
+	setB := toSet(b)
+	var common int
+	for w := range setA {
+		if setB[w] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(setA)+len(setB)-common)
+}
+
+// hasWordPrefix reports whether s starts with prefix followed by a word boundary, ignoring case.
+func hasWordPrefix(s, prefix string) bool {
+	if len(s) <= len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return false
+	}
+	r := rune(s[len(prefix)])
+	return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
+
+// containsFold reports whether list contains s, ignoring case.
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
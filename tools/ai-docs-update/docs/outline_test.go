@@ -0,0 +1,189 @@
+package docs
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseOutline(t *testing.T) {
+	content := `---
+title: Members
+---
+
+# Members
+
+## Inviting New Members
+
+` + "```md" + `
+## Not a heading
+` + "```" + `
+
+### Step 1: Open **Settings**
+
+## Roles {#member-roles}
+
+## What the AI Assistant can and can't do
+
+## Roles
+
+## [Linked](./other.md) heading ##
+`
+	want := []Heading{
+		{Level: 1, Text: "Members", Anchor: "members", StartLine: 5, EndLine: 21},
+		{Level: 2, Text: "Inviting New Members", Anchor: "inviting-new-members", StartLine: 7, EndLine: 14},
+		{Level: 3, Text: "Step 1: Open Settings", Anchor: "step-1-open-settings", StartLine: 13, EndLine: 14},
+		{Level: 2, Text: "Roles", Anchor: "member-roles", StartLine: 15, EndLine: 16},
+		{Level: 2, Text: "What the AI Assistant can and can't do", Anchor: "what-the-ai-assistant-can-and-cant-do", StartLine: 17, EndLine: 18},
+		{Level: 2, Text: "Roles", Anchor: "roles", StartLine: 19, EndLine: 20},
+		{Level: 2, Text: "Linked heading", Anchor: "linked-heading", StartLine: 21, EndLine: 21},
+	}
+	if got := ParseOutline(content); !slices.Equal(got, want) {
+		t.Errorf("ParseOutline() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseOutlineRepeatedAnchors(t *testing.T) {
+	got := ParseOutline("## Setup\n\n## Setup\n\n## Setup\n")
+	var anchors []string
+	for _, h := range got {
+		anchors = append(anchors, h.Anchor)
+	}
+	if want := []string{"setup", "setup-1", "setup-2"}; !slices.Equal(anchors, want) {
+		t.Errorf("anchors = %q, want %q", anchors, want)
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Inviting New Members", "inviting-new-members"},
+		{"What the AI Assistant can and can't do", "what-the-ai-assistant-can-and-cant-do"},
+		{"Adding, editing, and deleting", "adding-editing-and-deleting"},
+		{"Step 1: Open Settings", "step-1-open-settings"},
+		{"snake_case and kebab-case", "snake_case-and-kebab-case"},
+		{"Android (Kotlin)", "android-kotlin"},
+		{"フラグの作成", "フラグの作成"},
+	}
+	for _, tt := range tests {
+		if got := Slugify(tt.text); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestHeadingRefs(t *testing.T) {
+	doc := &DocFile{Outline: []Heading{
+		{Level: 2, Text: "What the AI Assistant can and can't do"},
+		{Level: 2, Text: "Adding, editing, and deleting"},
+		{Level: 2, Text: "Adding"},
+		{Level: 2, Text: "Inviting New Members"},
+		{Level: 3, Text: "Step 1: Open Settings"},
+	}}
+	tests := []struct {
+		name   string
+		target string
+		want   []string
+	}{
+		{
+			name:   "heading marker in quotes",
+			target: "In '## Inviting New Members' section, after step 4",
+			want:   []string{"Inviting New Members"},
+		},
+		{
+			name:   "apostrophe in heading",
+			target: "In '## What the AI Assistant can and can't do' section",
+			want:   []string{"What the AI Assistant can and can't do"},
+		},
+		{
+			name:   "commas in heading",
+			target: "In the '## Adding, editing, and deleting' section",
+			want:   []string{"Adding, editing, and deleting"},
+		},
+		{
+			name:   "unquoted heading with commas running into the sentence",
+			target: "## Adding, editing, and deleting section, after the table",
+			want:   []string{"Adding, editing, and deleting"},
+		},
+		{
+			name:   "shorter heading that prefixes a longer one",
+			target: "In the '## Adding' section",
+			want:   []string{"Adding"},
+		},
+		{
+			name:   "quoted name without marker",
+			target: "After the 'What the AI Assistant can and can't do' section",
+			want:   []string{"What the AI Assistant can and can't do"},
+		},
+		{
+			name:   "case differs from the heading",
+			target: "In '## inviting new members' section",
+			want:   []string{"Inviting New Members"},
+		},
+		{
+			name:   "two headings",
+			target: "Between '## Adding, editing, and deleting' and '### Step 1: Open Settings' sections",
+			want:   []string{"Adding, editing, and deleting", "Step 1: Open Settings"},
+		},
+		{
+			name:   "heading not in the doc",
+			target: "In '## Invite Members' section",
+			want:   []string{"Invite Members"},
+		},
+		{
+			name:   "heading not in the doc with an apostrophe",
+			target: "Add '## Can't find your flag?' section",
+			want:   []string{"Can't find your flag?"},
+		},
+		{
+			name:   "heading not in the doc ends at a comma",
+			target: "## Billing, after the table",
+			want:   []string{"Billing"},
+		},
+		{
+			name:   "quoted heading followed by section",
+			target: `Before the "Inviting New Members" heading`,
+			want:   []string{"Inviting New Members"},
+		},
+		{
+			name:   "no heading",
+			target: "End of document",
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := doc.HeadingRefs(tt.target); !slices.Equal(got, tt.want) {
+				t.Errorf("HeadingRefs(%q) = %q, want %q", tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindHeading(t *testing.T) {
+	doc := &DocFile{Outline: ParseOutline("## Inviting New Members\n\n## Roles {#member-roles}\n\n## Adding, editing, and deleting\n")}
+	tests := []struct {
+		ref       string
+		want      string
+		wantExact bool
+	}{
+		{ref: "Inviting New Members", want: "Inviting New Members", wantExact: true},
+		{ref: "inviting new members", want: "Inviting New Members", wantExact: true},
+		{ref: "member-roles", want: "Roles", wantExact: true},
+		{ref: "Roles section, after the table", want: "Roles", wantExact: true},
+		{ref: "Invite New Members", want: "Inviting New Members", wantExact: false},
+		{ref: "Add, edit, and delete", want: "Adding, editing, and deleting", wantExact: false},
+		{ref: "Billing", want: "", wantExact: false},
+	}
+	for _, tt := range tests {
+		h, exact := doc.FindHeading(tt.ref)
+		var got string
+		if h != nil {
+			got = h.Text
+		}
+		if got != tt.want || exact != tt.wantExact {
+			t.Errorf("FindHeading(%q) = %q, %v; want %q, %v", tt.ref, got, exact, tt.want, tt.wantExact)
+		}
+	}
+}
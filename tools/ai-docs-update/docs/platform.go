@@ -0,0 +1,70 @@
+package docs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrPlatformMismatch indicates an update targeting a doc outside the SDK
+// platforms a change was routed to.
+var ErrPlatformMismatch = errors.New("doc is not for the platform of the changed SDK")
+
+// PlatformTarget is an SDK platform, optionally limited to one SDK kind.
+type PlatformTarget struct {
+	Platform string  `json:"platform"`
+	SDKKind  SDKKind `json:"sdk_kind,omitempty"` // empty matches native and OpenFeature docs
+}
+
+// Matches reports whether f documents the target's platform (and SDK kind, if set).
+func (t PlatformTarget) Matches(f *DocFile) bool {
+	return f.Platform == t.Platform && (t.SDKKind == "" || f.SDKKind == t.SDKKind)
+}
+
+// String returns "platform" or "platform (kind)".
+func (t PlatformTarget) String() string {
+	if t.SDKKind == "" {
+		return t.Platform
+	}
+	return fmt.Sprintf("%s (%s)", t.Platform, t.SDKKind)
+}
+
+// PlatformRoute limits updates to the docs of the SDK platforms a change
+// comes from. An empty route allows every doc.
+type PlatformRoute []PlatformTarget
+
+// Allows reports whether f may be updated under the route.
+func (r PlatformRoute) Allows(f *DocFile) bool {
+	if len(r) == 0 {
+		return true
+	}
+	for _, t := range r {
+		if t.Matches(f) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns a manifest of the docs the route allows, in manifest order.
+func (r PlatformRoute) Filter(m *Manifest) *Manifest {
+	if len(r) == 0 {
+		return m
+	}
+	filtered := &Manifest{SchemaVersion: m.SchemaVersion}
+	for i := range m.Files {
+		if r.Allows(&m.Files[i]) {
+			filtered.Files = append(filtered.Files, m.Files[i])
+		}
+	}
+	return filtered
+}
+
+// String returns the route's targets, comma-separated.
+func (r PlatformRoute) String() string {
+	targets := make([]string, len(r))
+	for i, t := range r {
+		targets[i] = t.String()
+	}
+	return strings.Join(targets, ", ")
+}
@@ -0,0 +1,322 @@
+package docs
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ErrStructureChanged indicates an update that dropped or broke the doc's
+// MDX structure (imports, tab groups or admonitions).
+var ErrStructureChanged = errors.New("document structure not preserved")
+
+// Structure is the MDX structure of a doc.
+type Structure struct {
+	Imports     []Import       `json:"imports,omitempty"`
+	TabGroups   []TabGroup     `json:"tab_groups,omitempty"`
+	Admonitions map[string]int `json:"admonitions,omitempty"` // Admonition type (note, tip, ...) -> count
+	Unbalanced  []string       `json:"unbalanced,omitempty"`  // Unclosed or stray <Tabs>, <TabItem> and ::: markers
+}
+
+// Import is an MDX import statement.
+type Import struct {
+	Names  []string `json:"names,omitempty"` // Imported local names (e.g. "Tabs", "TabItem")
+	Source string   `json:"source"`          // Module (e.g. "@theme/Tabs")
+}
+
+// TabGroup is a <Tabs> component and the labels of its <TabItem>s.
+type TabGroup struct {
+	Line    int      `json:"line"`               // 1-based line of <Tabs>
+	GroupID string   `json:"group_id,omitempty"` // groupId attribute (tab choice synced across groups)
+	Labels  []string `json:"labels"`             // TabItem labels, or values if unlabeled
+}
+
+var (
+	importStmtPattern  = regexp.MustCompile(`(?s)^import\s+(?:(.+?)\s+from\s+)?['"]([^'"]+)['"]`)
+	tabsOpenPattern    = regexp.MustCompile(`<Tabs\b([^>]*)>`)
+	tabItemOpenPattern = regexp.MustCompile(`<TabItem\b([^>]*)>`)
+	jsxAttrPattern     = regexp.MustCompile(`(\w+)=(?:"([^"]*)"|'([^']*)')`)
+	admonitionPattern  = regexp.MustCompile(`^:{3,}\s*([A-Za-z]*)`)
+)
+
+// ParseStructure returns the imports, tab groups and admonitions of a doc,
+// skipping frontmatter and fenced code.
+func ParseStructure(content string) Structure {
+	lines := strings.Split(content, "\n")
+	s := Structure{Admonitions: make(map[string]int)}
+
+	var tabs []int        // Indexes into s.TabGroups of open <Tabs>
+	var items []int       // Lines of open <TabItem>s
+	var admonitions []int // Lines of open admonitions
+	var admonitionTypes []string
+	fence := ""
+	for i := frontMatterEnd(lines); i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if f := openingFence(trimmed); f != "" {
+			fence = f
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "import ") {
+			// Multi-line imports ("import {\n  A,\n} from '...'") end at the module string
+			stmt := trimmed
+			for !strings.Contains(stmt, "'") && !strings.Contains(stmt, `"`) && i+1 < len(lines) {
+				i++
+				stmt += " " + strings.TrimSpace(lines[i])
+			}
+			if imp, ok := parseImport(stmt); ok {
+				s.Imports = append(s.Imports, imp)
+			}
+			continue
+		}
+
+		if m := admonitionPattern.FindStringSubmatch(trimmed); m != nil {
+			if m[1] != "" {
+				admonitions = append(admonitions, i+1)
+				admonitionTypes = append(admonitionTypes, m[1])
+				s.Admonitions[m[1]]++
+			} else if len(admonitions) > 0 {
+				admonitions = admonitions[:len(admonitions)-1]
+				admonitionTypes = admonitionTypes[:len(admonitionTypes)-1]
+			} else {
+				s.Unbalanced = append(s.Unbalanced, fmt.Sprintf("line %d: ::: without an open admonition", i+1))
+			}
+			continue
+		}
+
+		for _, tag := range jsxTags(lines[i]) {
+			switch {
+			case strings.HasPrefix(tag, "<Tabs"):
+				attrs := jsxAttrs(tag)
+				s.TabGroups = append(s.TabGroups, TabGroup{Line: i + 1, GroupID: attrs["groupId"], Labels: []string{}})
+				tabs = append(tabs, len(s.TabGroups)-1)
+			case tag == "</Tabs>":
+				if len(tabs) == 0 {
+					s.Unbalanced = append(s.Unbalanced, fmt.Sprintf("line %d: </Tabs> without <Tabs>", i+1))
+					continue
+				}
+				tabs = tabs[:len(tabs)-1]
+			case strings.HasPrefix(tag, "<TabItem"):
+				attrs := jsxAttrs(tag)
+				label := attrs["label"]
+				if label == "" {
+					label = attrs["value"]
+				}
+				if len(tabs) > 0 {
+					g := &s.TabGroups[tabs[len(tabs)-1]]
+					g.Labels = append(g.Labels, label)
+				}
+				items = append(items, i+1)
+			case tag == "</TabItem>":
+				if len(items) == 0 {
+					s.Unbalanced = append(s.Unbalanced, fmt.Sprintf("line %d: </TabItem> without <TabItem>", i+1))
+					continue
+				}
+				items = items[:len(items)-1]
+			}
+		}
+	}
+
+	for _, g := range tabs {
+		s.Unbalanced = append(s.Unbalanced, fmt.Sprintf("line %d: unclosed <Tabs>", s.TabGroups[g].Line))
+	}
+	for _, line := range items {
+		s.Unbalanced = append(s.Unbalanced, fmt.Sprintf("line %d: unclosed <TabItem>", line))
+	}
+	for j, line := range admonitions {
+		s.Unbalanced = append(s.Unbalanced, fmt.Sprintf("line %d: unclosed :::%s", line, admonitionTypes[j]))
+	}
+	if len(s.Admonitions) == 0 {
+		s.Admonitions = nil
+	}
+	return s
+}
+
+// parseImport parses an import statement into its local names and module.
+func parseImport(stmt string) (Import, bool) {
+	m := importStmtPattern.FindStringSubmatch(stmt)
+	if m == nil {
+		return Import{}, false
+	}
+	imp := Import{Source: m[2]}
+	for _, part := range strings.FieldsFunc(m[1], func(r rune) bool { return r == ',' || r == '{' || r == '}' }) {
+		// "A as B" imports B; "* as ns" imports ns
+		fields := strings.Fields(part)
+		if len(fields) > 0 {
+			imp.Names = append(imp.Names, fields[len(fields)-1])
+		}
+	}
+	return imp, true
+}
+
+// jsxTags returns the <Tabs>, </Tabs>, <TabItem ...> and </TabItem> tags in
+// a line, in order, ignoring inline code.
+func jsxTags(line string) []string {
+	line = inlineCodePattern.ReplaceAllString(line, "")
+	type match struct {
+		pos int
+		tag string
+	}
+	var matches []match
+	for _, p := range []*regexp.Regexp{tabsOpenPattern, tabItemOpenPattern} {
+		for _, loc := range p.FindAllStringIndex(line, -1) {
+			matches = append(matches, match{loc[0], line[loc[0]:loc[1]]})
+		}
+	}
+	for _, closing := range []string{"</Tabs>", "</TabItem>"} {
+		for offset := 0; ; {
+			idx := strings.Index(line[offset:], closing)
+			if idx < 0 {
+				break
+			}
+			matches = append(matches, match{offset + idx, closing})
+			offset += idx + len(closing)
+		}
+	}
+	slices.SortFunc(matches, func(a, b match) int { return a.pos - b.pos })
+
+	tags := make([]string, len(matches))
+	for i, m := range matches {
+		tags[i] = m.tag
+	}
+	return tags
+}
+
+// jsxAttrs returns the string attributes of a JSX tag.
+func jsxAttrs(tag string) map[string]string {
+	attrs := make(map[string]string)
+	for _, m := range jsxAttrPattern.FindAllStringSubmatch(tag, -1) {
+		attrs[m[1]] = m[2] + m[3]
+	}
+	return attrs
+}
+
+// ImportsTabs reports whether the doc imports both Tabs and TabItem, so that
+// new tab groups can be added without adding imports.
+func (s Structure) ImportsTabs() bool {
+	var tabs, tabItem bool
+	for _, imp := range s.Imports {
+		tabs = tabs || slices.Contains(imp.Names, "Tabs")
+		tabItem = tabItem || slices.Contains(imp.Names, "TabItem")
+	}
+	return tabs && tabItem
+}
+
+// IsEmpty reports whether the doc has no imports, tab groups or admonitions.
+func (s Structure) IsEmpty() bool {
+	return len(s.Imports) == 0 && len(s.TabGroups) == 0 && len(s.Admonitions) == 0 && len(s.Unbalanced) == 0
+}
+
+// Describe returns a summary of the structure for prompts, one line per kind,
+// or "" if there is none. Tab groups with the same labels are listed once
+// with their count.
+func (s Structure) Describe() string {
+	var lines []string
+	if len(s.Imports) > 0 {
+		var imports []string
+		for _, imp := range s.Imports {
+			if len(imp.Names) == 0 {
+				imports = append(imports, imp.Source)
+				continue
+			}
+			imports = append(imports, fmt.Sprintf("%s (%s)", strings.Join(imp.Names, ", "), imp.Source))
+		}
+		lines = append(lines, "Imports: "+strings.Join(imports, "; "))
+	}
+	if len(s.TabGroups) > 0 {
+		var order []string
+		counts := make(map[string]int)
+		for _, g := range s.TabGroups {
+			key := strings.Join(g.Labels, " | ")
+			if counts[key] == 0 {
+				order = append(order, key)
+			}
+			counts[key]++
+		}
+		groups := make([]string, len(order))
+		for i, key := range order {
+			groups[i] = fmt.Sprintf("[%s] x%d", key, counts[key])
+		}
+		lines = append(lines, fmt.Sprintf("Tab groups (%d): %s", len(s.TabGroups), strings.Join(groups, ", ")))
+	}
+	if len(s.Admonitions) > 0 {
+		var admonitions []string
+		for _, t := range slices.Sorted(maps.Keys(s.Admonitions)) {
+			admonitions = append(admonitions, fmt.Sprintf(":::%s x%d", t, s.Admonitions[t]))
+		}
+		lines = append(lines, "Admonitions: "+strings.Join(admonitions, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CheckStructure compares a doc's structure before and after an update.
+// Updates may add tab groups, TabItems and admonitions, but must keep every
+// existing one, must not add or drop imports, and must not leave components
+// or admonitions unclosed.
+func CheckStructure(before, after Structure) error {
+	var problems []string
+
+	for _, imp := range before.Imports {
+		if !slices.ContainsFunc(after.Imports, func(a Import) bool {
+			return a.Source == imp.Source && containsAll(a.Names, imp.Names)
+		}) {
+			problems = append(problems, fmt.Sprintf("import from %s removed", imp.Source))
+		}
+	}
+	for _, imp := range after.Imports {
+		if !slices.ContainsFunc(before.Imports, func(b Import) bool { return b.Source == imp.Source }) {
+			problems = append(problems, fmt.Sprintf("import from %s added", imp.Source))
+		}
+	}
+
+	// Existing groups must appear in order, each with at least its old labels
+	next := 0
+	for _, g := range before.TabGroups {
+		found := false
+		for ; next < len(after.TabGroups); next++ {
+			if containsAll(after.TabGroups[next].Labels, g.Labels) {
+				found = true
+				next++
+				break
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("tab group [%s] at line %d removed or changed", strings.Join(g.Labels, " | "), g.Line))
+			break
+		}
+	}
+
+	for _, t := range slices.Sorted(maps.Keys(before.Admonitions)) {
+		if after.Admonitions[t] < before.Admonitions[t] {
+			problems = append(problems, fmt.Sprintf(":::%s admonitions reduced from %d to %d", t, before.Admonitions[t], after.Admonitions[t]))
+		}
+	}
+
+	if len(after.Unbalanced) > len(before.Unbalanced) {
+		problems = append(problems, after.Unbalanced...)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrStructureChanged, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// containsAll reports whether have contains every item of want.
+func containsAll(have, want []string) bool {
+	for _, w := range want {
+		if !slices.Contains(have, w) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,167 @@
+package docs
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const tabbedDoc = `---
+title: Android
+---
+
+import Tabs from '@theme/Tabs';
+import TabItem from '@theme/TabItem';
+import {
+  CenteredImg,
+  Badge as Label,
+} from '@site/src/components';
+
+## Install
+
+<Tabs groupId="language">
+<TabItem value="kotlin" label="Kotlin">
+
+` + "```kotlin" + `
+val client = BKTClient.getInstance()
+// <Tabs> in code is ignored
+` + "```" + `
+
+</TabItem>
+<TabItem value="java">
+
+Use ` + "`<TabItem>`" + ` for each language.
+
+</TabItem>
+</Tabs>
+
+:::note
+Requires Android 5.0.
+:::
+
+:::tip Title
+Nested <Tabs><TabItem value="a" label="A">x</TabItem></Tabs>
+:::
+`
+
+func TestParseStructure(t *testing.T) {
+	want := Structure{
+		Imports: []Import{
+			{Names: []string{"Tabs"}, Source: "@theme/Tabs"},
+			{Names: []string{"TabItem"}, Source: "@theme/TabItem"},
+			{Names: []string{"CenteredImg", "Label"}, Source: "@site/src/components"},
+		},
+		TabGroups: []TabGroup{
+			{Line: 14, GroupID: "language", Labels: []string{"Kotlin", "java"}},
+			{Line: 35, Labels: []string{"A"}},
+		},
+		Admonitions: map[string]int{"note": 1, "tip": 1},
+	}
+	if got := ParseStructure(tabbedDoc); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseStructure() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseStructureUnbalanced(t *testing.T) {
+	content := "<Tabs>\n<TabItem value=\"a\">\n</Tabs>\n</TabItem>\n</TabItem>\n:::\n:::warning\n"
+	want := []string{
+		"line 5: </TabItem> without <TabItem>",
+		"line 6: ::: without an open admonition",
+		"line 7: unclosed :::warning",
+	}
+	if got := ParseStructure(content).Unbalanced; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unbalanced = %q, want %q", got, want)
+	}
+}
+
+func TestStructureImportsTabs(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{name: "both imported", content: tabbedDoc, want: true},
+		{name: "only Tabs", content: "import Tabs from '@theme/Tabs';\n", want: false},
+		{name: "none", content: "## Install\n", want: false},
+		{name: "renamed", content: "import { default as Tabs } from '@theme/Tabs';\nimport TabItem from '@theme/TabItem';\n", want: true},
+	}
+	for _, tt := range tests {
+		if got := ParseStructure(tt.content).ImportsTabs(); got != tt.want {
+			t.Errorf("%s: ImportsTabs() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStructureDescribe(t *testing.T) {
+	s := ParseStructure(tabbedDoc + "\n<Tabs>\n<TabItem label=\"A\">\n</TabItem>\n</Tabs>\n")
+	want := strings.Join([]string{
+		"Imports: Tabs (@theme/Tabs); TabItem (@theme/TabItem); CenteredImg, Label (@site/src/components)",
+		"Tab groups (3): [Kotlin | java] x1, [A] x2",
+		"Admonitions: :::note x1, :::tip x1",
+	}, "\n")
+	if got := s.Describe(); got != want {
+		t.Errorf("Describe() =\n%s\nwant\n%s", got, want)
+	}
+	if got := ParseStructure("## Plain\n").Describe(); got != "" {
+		t.Errorf("Describe() of a plain doc = %q, want empty", got)
+	}
+}
+
+func TestCheckStructure(t *testing.T) {
+	before := ParseStructure(tabbedDoc)
+	tests := []struct {
+		name    string
+		after   string
+		wantErr string
+	}{
+		{name: "unchanged", after: tabbedDoc},
+		{
+			name:  "tab and admonition added",
+			after: tabbedDoc + "\n<Tabs>\n<TabItem label=\"Go\">\n</TabItem>\n</Tabs>\n\n:::info\nNew.\n:::\n",
+		},
+		{
+			name:  "label added to a group",
+			after: strings.Replace(tabbedDoc, "</Tabs>\n\n:::note", "<TabItem value=\"swift\">\n</TabItem>\n</Tabs>\n\n:::note", 1),
+		},
+		{
+			name:    "import removed",
+			after:   strings.Replace(tabbedDoc, "import TabItem from '@theme/TabItem';\n", "", 1),
+			wantErr: "import from @theme/TabItem removed",
+		},
+		{
+			name:    "import added",
+			after:   "import Admonition from '@theme/Admonition';\n" + tabbedDoc,
+			wantErr: "import from @theme/Admonition added",
+		},
+		{
+			name:    "tab item removed",
+			after:   strings.Replace(tabbedDoc, `<TabItem value="java">`, `<TabItem value="swift">`, 1),
+			wantErr: "tab group [Kotlin | java] at line 14 removed or changed",
+		},
+		{
+			name:    "admonition removed",
+			after:   strings.Replace(tabbedDoc, ":::note\nRequires Android 5.0.\n:::\n", "", 1),
+			wantErr: ":::note admonitions reduced from 1 to 0",
+		},
+		{
+			name:    "admonition left open",
+			after:   tabbedDoc + "\n:::caution\nOpen.\n",
+			wantErr: "unclosed :::caution",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckStructure(before, ParseStructure(tt.after))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckStructure() error = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrStructureChanged) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CheckStructure() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
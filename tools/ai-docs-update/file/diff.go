@@ -0,0 +1,231 @@
+package file
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffContextLines is the number of unchanged lines shown around each change.
+const DiffContextLines = 3
+
+// editOp identifies a single line operation in an edit script.
+type editOp int
+
+const (
+	opEqual editOp = iota
+	opDelete
+	opInsert
+)
+
+// edit is a single line operation with its position in both files.
+type edit struct {
+	op      editOp
+	oldLine int // 0-based index into old lines (valid for equal/delete)
+	newLine int // 0-based index into new lines (valid for equal/insert)
+}
+
+// UnifiedDiff returns a unified diff between oldContent and newContent.
+// The path is used for the "--- a/" and "+++ b/" headers so the output can be
+// applied with `git apply`. Returns an empty string if the contents are identical.
+func UnifiedDiff(path, oldContent, newContent string) string {
+	if oldContent == newContent {
+		return ""
+	}
+
+	oldLines := splitLines(oldContent)
+	newLines := splitLines(newContent)
+	edits := myersDiff(oldLines, newLines)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- a/%s\n", path)
+	fmt.Fprintf(&sb, "+++ b/%s\n", path)
+
+	for _, h := range groupHunks(edits, DiffContextLines) {
+		writeHunk(&sb, h, oldLines, newLines)
+	}
+
+	return sb.String()
+}
+
+// splitLines splits content into lines, keeping the trailing newline on each line.
+// A final line without a newline is kept as-is so it can be marked in the diff.
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// myersDiff computes the shortest edit script between a and b
+// using Myers' O(ND) algorithm.
+func myersDiff(a, b []string) []edit {
+	n, m := len(a), len(b)
+	maxD := n + m
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	var trace [][]int
+
+	for d := 0; d <= maxD; d++ {
+		// Keep only the diagonals reachable at this step (-d-1..d+1)
+		// so memory grows with the edit distance, not the file size.
+		snapshot := make([]int, 2*d+3)
+		copy(snapshot, v[offset-d-1:offset+d+2])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // move down (insertion)
+			} else {
+				x = v[offset+k-1] + 1 // move right (deletion)
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+
+	return nil
+}
+
+// backtrack walks the Myers trace backwards to build the edit script.
+func backtrack(trace [][]int, a, b []string) []edit {
+	x, y := len(a), len(b)
+	var edits []edit
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d+1] }
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, edit{op: opEqual, oldLine: x, newLine: y})
+		}
+
+		if d > 0 {
+			if x == prevX {
+				y--
+				edits = append(edits, edit{op: opInsert, oldLine: x, newLine: y})
+			} else {
+				x--
+				edits = append(edits, edit{op: opDelete, oldLine: x, newLine: y})
+			}
+		}
+	}
+
+	// Reverse into forward order
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// hunk is a contiguous range of edits including surrounding context.
+type hunk struct {
+	edits []edit
+}
+
+// groupHunks splits an edit script into hunks, keeping up to context
+// unchanged lines around each change and merging hunks that overlap.
+func groupHunks(edits []edit, context int) []hunk {
+	var hunks []hunk
+	start, end := -1, -1
+
+	for i, e := range edits {
+		if e.op == opEqual {
+			continue
+		}
+		lo := max(i-context, 0)
+		hi := min(i+context+1, len(edits))
+		if start != -1 && lo <= end {
+			end = hi
+			continue
+		}
+		if start != -1 {
+			hunks = append(hunks, hunk{edits: edits[start:end]})
+		}
+		start, end = lo, hi
+	}
+	if start != -1 {
+		hunks = append(hunks, hunk{edits: edits[start:end]})
+	}
+
+	return hunks
+}
+
+// writeHunk writes a single hunk with its "@@" header.
+func writeHunk(sb *strings.Builder, h hunk, oldLines, newLines []string) {
+	oldStart, newStart := -1, -1
+	oldCount, newCount := 0, 0
+	for _, e := range h.edits {
+		if e.op != opInsert {
+			if oldStart == -1 {
+				oldStart = e.oldLine
+			}
+			oldCount++
+		}
+		if e.op != opDelete {
+			if newStart == -1 {
+				newStart = e.newLine
+			}
+			newCount++
+		}
+	}
+
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n",
+		hunkRange(oldStart, oldCount, h.edits[0].oldLine),
+		hunkRange(newStart, newCount, h.edits[0].newLine))
+
+	for _, e := range h.edits {
+		switch e.op {
+		case opEqual:
+			writeDiffLine(sb, " ", oldLines[e.oldLine])
+		case opDelete:
+			writeDiffLine(sb, "-", oldLines[e.oldLine])
+		case opInsert:
+			writeDiffLine(sb, "+", newLines[e.newLine])
+		}
+	}
+}
+
+// hunkRange formats a "start,count" range. Empty ranges point at the line
+// before the change, following the GNU diff convention.
+func hunkRange(start, count, fallback int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", fallback)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// writeDiffLine writes a prefixed line, marking a missing trailing newline.
+func writeDiffLine(sb *strings.Builder, prefix, line string) {
+	sb.WriteString(prefix)
+	sb.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		sb.WriteString("\n\\ No newline at end of file\n")
+	}
+}
@@ -0,0 +1,145 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// numbered returns lines "line 1\n" through "line n\n", with the given line
+// numbers replaced.
+func numbered(n int, replace map[int]string) string {
+	var sb strings.Builder
+	for i := 1; i <= n; i++ {
+		if line, ok := replace[i]; ok {
+			sb.WriteString(line)
+			continue
+		}
+		fmt.Fprintf(&sb, "line %d\n", i)
+	}
+	return sb.String()
+}
+
+var diffTests = []struct {
+	name string
+	old  string
+	new  string
+	want string // without the file headers; "" for identical contents
+}{
+	{
+		name: "identical",
+		old:  "a\nb\n",
+		new:  "a\nb\n",
+	},
+	{
+		name: "empty old file",
+		old:  "",
+		new:  "# Title\n\nBody\n",
+		want: "@@ -0,0 +1,3 @@\n+# Title\n+\n+Body\n",
+	},
+	{
+		name: "empty new file",
+		old:  "# Title\nBody\n",
+		new:  "",
+		want: "@@ -1,2 +0,0 @@\n-# Title\n-Body\n",
+	},
+	{
+		name: "missing trailing newline added",
+		old:  "a\nb",
+		new:  "a\nb\n",
+		want: "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+	},
+	{
+		name: "missing trailing newline kept",
+		old:  "a\nb\nc",
+		new:  "a\nB\nc",
+		want: "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n\\ No newline at end of file\n",
+	},
+	{
+		name: "single line",
+		old:  "x\n",
+		new:  "y\n",
+		want: "@@ -1 +1 @@\n-x\n+y\n",
+	},
+	{
+		name: "separate hunks",
+		old:  numbered(20, nil),
+		new:  numbered(20, map[int]string{2: "line two\n", 18: "line 18\nline 18b\n"}),
+		want: "@@ -1,5 +1,5 @@\n line 1\n-line 2\n+line two\n line 3\n line 4\n line 5\n" +
+			"@@ -16,5 +16,6 @@\n line 16\n line 17\n line 18\n+line 18b\n line 19\n line 20\n",
+	},
+	{
+		name: "overlapping context is merged",
+		old:  numbered(12, nil),
+		new:  numbered(12, map[int]string{2: "line two\n", 8: ""}),
+		want: "@@ -1,11 +1,10 @@\n line 1\n-line 2\n+line two\n line 3\n line 4\n line 5\n line 6\n line 7\n-line 8\n line 9\n line 10\n line 11\n",
+	},
+	{
+		name: "inserted at the start and deleted at the end",
+		old:  "b\nc\nd\n",
+		new:  "a\nb\nc\n",
+		want: "@@ -1,3 +1,3 @@\n+a\n b\n c\n-d\n",
+	},
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	for _, tt := range diffTests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.want
+			if want != "" {
+				want = "--- a/docs/page.mdx\n+++ b/docs/page.mdx\n" + want
+			}
+			if got := UnifiedDiff("docs/page.mdx", tt.old, tt.new); got != want {
+				t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestUnifiedDiffGitApply checks that git accepts the diffs and that applying
+// them gives the new contents.
+func TestUnifiedDiffGitApply(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	cases := diffTests[1:] // git apply rejects an empty patch
+	cases = append(cases, struct{ name, old, new, want string }{
+		name: "rewritten document",
+		old:  "---\ntitle: Segments\n---\n\n## Rules\n\nMatch by user attribute.\n\n## Limits\n\nUp to 100 rules.\n",
+		new:  "---\ntitle: Segments\n---\n\n## Rules\n\nMatch by user attribute or country.\n\n### Country\n\nUse ISO codes.\n\n## Limits\n\nUp to 100 rules.",
+	})
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "docs", "page.mdx")
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(tt.old), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			patch := filepath.Join(dir, "page.patch")
+			if err := os.WriteFile(patch, []byte(UnifiedDiff("docs/page.mdx", tt.old, tt.new)), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, args := range [][]string{{"apply", "--check", patch}, {"apply", patch}} {
+				cmd := exec.Command("git", args...)
+				cmd.Dir = dir
+				if out, err := cmd.CombinedOutput(); err != nil {
+					t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+				}
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.new {
+				t.Errorf("applied diff gives\n%q\nwant\n%q", got, tt.new)
+			}
+		})
+	}
+}
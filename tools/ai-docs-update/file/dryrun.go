@@ -0,0 +1,57 @@
+package file
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// DryRunWriter computes unified diffs instead of writing files.
+// It applies the same manifest validation as Writer so a dry run
+// rejects exactly the paths a real run would reject.
+type DryRunWriter struct {
+	rootDir      string
+	allowedPaths map[string]bool
+	out          io.Writer
+	changed      int
+}
+
+// NewDryRunWriter creates a DryRunWriter that writes diffs to out.
+func NewDryRunWriter(rootDir string, manifestPaths []string, out io.Writer) *DryRunWriter {
+	return &DryRunWriter{
+		rootDir:      rootDir,
+		allowedPaths: toAllowedPaths(manifestPaths),
+		out:          out,
+	}
+}
+
+// Write compares content against the current file and writes a unified diff.
+// The file on disk is never modified. Identical content produces no output.
+func (w *DryRunWriter) Write(relativePath, content string) error {
+	if err := checkAllowed(w.allowedPaths, relativePath); err != nil {
+		return err
+	}
+
+	current, err := os.ReadFile(filepath.Join(w.rootDir, relativePath))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read current content: %w", err)
+	}
+
+	diff := UnifiedDiff(filepath.ToSlash(relativePath), string(current), content)
+	if diff == "" {
+		return nil
+	}
+
+	if _, err := io.WriteString(w.out, diff); err != nil {
+		return fmt.Errorf("failed to write diff: %w", err)
+	}
+	w.changed++
+
+	return nil
+}
+
+// ChangedCount returns the number of files that produced a non-empty diff.
+func (w *DryRunWriter) ChangedCount() int {
+	return w.changed
+}
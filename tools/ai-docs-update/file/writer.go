@@ -19,22 +19,17 @@ type Writer struct {
 
 // NewWriter creates a Writer that only allows writing to manifest paths.
 func NewWriter(rootDir string, manifestPaths []string) *Writer {
-	allowed := make(map[string]bool, len(manifestPaths))
-	for _, p := range manifestPaths {
-		allowed[filepath.ToSlash(p)] = true
-	}
 	return &Writer{
 		rootDir:      rootDir,
-		allowedPaths: allowed,
+		allowedPaths: toAllowedPaths(manifestPaths),
 	}
 }
 
 // Write writes content to a file if the path is in the manifest.
 func (w *Writer) Write(relativePath, content string) error {
 	// Validate path is in manifest
-	normalized := filepath.ToSlash(relativePath)
-	if !w.allowedPaths[normalized] {
-		return fmt.Errorf("%w: %s", ErrPathNotInManifest, relativePath)
+	if err := checkAllowed(w.allowedPaths, relativePath); err != nil {
+		return err
 	}
 
 	fullPath := filepath.Join(w.rootDir, relativePath)
@@ -71,3 +66,20 @@ func (w *Writer) Write(relativePath, content string) error {
 
 	return nil
 }
+
+// toAllowedPaths builds the set of writable paths from manifest paths.
+func toAllowedPaths(manifestPaths []string) map[string]bool {
+	allowed := make(map[string]bool, len(manifestPaths))
+	for _, p := range manifestPaths {
+		allowed[filepath.ToSlash(p)] = true
+	}
+	return allowed
+}
+
+// checkAllowed returns ErrPathNotInManifest if the path is not in the allowed set.
+func checkAllowed(allowed map[string]bool, relativePath string) error {
+	if !allowed[filepath.ToSlash(relativePath)] {
+		return fmt.Errorf("%w: %s", ErrPathNotInManifest, relativePath)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package guardrails
+
+import (
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/unidiff"
+)
+
+// Changed files that never inform documentation, as doublestar globs.
+var (
+	lockfilePatterns = []string{
+		"**/{package-lock.json,yarn.lock,pnpm-lock.yaml,go.sum,Cargo.lock,Gemfile.lock,poetry.lock,Podfile.lock,pubspec.lock,composer.lock}",
+	}
+	vendoredPatterns  = []string{"**/vendor/**", "**/node_modules/**", "**/Pods/**"}
+	generatedPatterns = []string{
+		"**/*.pb.go", "**/*.pb.gw.go", "**/*_pb.{js,ts,d.ts}", "**/*_pb_service.*", "**/*.pb.dart", "**/*.g.dart",
+		"**/*.generated.*", "**/generated/**", "**/zz_generated*", "**/*.min.{js,css}", "**/dist/**",
+	}
+)
+
+// DefaultDeniedSourcePaths are the changed files left out of the diff by
+// default: generated code, lockfiles and vendored trees.
+var DefaultDeniedSourcePaths = concatPatterns(generatedPatterns, lockfilePatterns, vendoredPatterns)
+
+func concatPatterns(lists ...[]string) []string {
+	var patterns []string
+	for _, l := range lists {
+		patterns = append(patterns, l...)
+	}
+	return patterns
+}
+
+// IsAllowedPath checks if a changed file path passes the source path filters:
+// it must match one of AllowedSourcePaths (if any) and none of
+// DeniedSourcePaths. Patterns are doublestar globs.
+func (g *InputGuardrails) IsAllowedPath(path string) bool {
+	if len(g.AllowedSourcePaths) > 0 && !matchAny(g.AllowedSourcePaths, path) {
+		return false
+	}
+	return !matchAny(g.DeniedSourcePaths, path)
+}
+
+// FilterDiff removes the files that fail the source path filters from a
+// diff. It returns the remaining diff and the paths of the removed files.
+// A renamed or copied file is kept if either of its paths is allowed.
+func (g *InputGuardrails) FilterDiff(diff string) (string, []string) {
+	if diff == "" || (len(g.AllowedSourcePaths) == 0 && len(g.DeniedSourcePaths) == 0) {
+		return diff, nil
+	}
+
+	parsed := unidiff.Parse(diff)
+	var kept strings.Builder
+	var filtered []string
+	for _, f := range parsed.Files {
+		if (f.NewPath != "" && g.IsAllowedPath(f.NewPath)) || (f.OldPath != "" && g.IsAllowedPath(f.OldPath)) {
+			kept.WriteString(f.Raw)
+		} else {
+			filtered = append(filtered, f.Path())
+		}
+	}
+	if len(filtered) == 0 {
+		return diff, nil
+	}
+	return kept.String(), filtered
+}
+
+// matchAny reports whether path matches any of the doublestar patterns.
+func matchAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := doublestar.Match(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package guardrails
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIsAllowedPath(t *testing.T) {
+	defaults := NewInputGuardrails()
+	restricted := NewInputGuardrails()
+	restricted.AllowedSourcePaths = []string{"pkg/**", "ui/**"}
+	restricted.DeniedSourcePaths = append(restricted.DeniedSourcePaths, "pkg/internal/**")
+
+	tests := []struct {
+		name string
+		g    *InputGuardrails
+		path string
+		want bool
+	}{
+		{name: "source", g: defaults, path: "pkg/feature/evaluator.go", want: true},
+		{name: "generated Go", g: defaults, path: "proto/feature/service.pb.go", want: false},
+		{name: "generated TypeScript", g: defaults, path: "ui/web/src/proto/feature/service_pb.ts", want: false},
+		{name: "go.sum", g: defaults, path: "go.sum", want: false},
+		{name: "nested lockfile", g: defaults, path: "ui/web/yarn.lock", want: false},
+		{name: "vendored", g: defaults, path: "vendor/github.com/x/y/z.go", want: false},
+		{name: "node_modules", g: defaults, path: "ui/web/node_modules/react/index.js", want: false},
+		{name: "proto source", g: defaults, path: "proto/feature/service.proto", want: true},
+		{name: "included", g: restricted, path: "ui/web/src/pages/flags.tsx", want: true},
+		{name: "not included", g: restricted, path: "docs/README.md", want: false},
+		{name: "excluded wins over included", g: restricted, path: "pkg/internal/cache.go", want: false},
+		{name: "default deny wins over included", g: restricted, path: "pkg/vendor/x.go", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.g.IsAllowedPath(tt.path); got != tt.want {
+				t.Errorf("IsAllowedPath(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterDiff(t *testing.T) {
+	rename := func(from, to string) string {
+		return "diff --git a/" + from + " b/" + to + "\nsimilarity index 100%\nrename from " + from + "\nrename to " + to + "\n"
+	}
+	binary := func(path string) string {
+		return "diff --git a/" + path + " b/" + path + "\nindex 1111111..2222222 100644\nBinary files a/" + path + " and b/" + path + " differ\n"
+	}
+	deleted := "diff --git a/go.sum b/go.sum\ndeleted file mode 100644\nindex 1111111..0000000\n--- a/go.sum\n+++ /dev/null\n@@ -1 +0,0 @@\n-a v1\n"
+
+	restricted := NewInputGuardrails()
+	restricted.AllowedSourcePaths = []string{"pkg/**", "ui/**"}
+	restricted.DeniedSourcePaths = append(restricted.DeniedSourcePaths, "pkg/internal/**")
+	unfiltered := NewInputGuardrails()
+	unfiltered.DeniedSourcePaths = nil
+
+	tests := []struct {
+		name         string
+		g            *InputGuardrails
+		diff         string
+		wantKept     string
+		wantFiltered []string
+	}{
+		{
+			name:     "nothing filtered",
+			g:        NewInputGuardrails(),
+			diff:     fileDiff("pkg/feature/evaluator.go", 2) + binary("ui/web/logo.png"),
+			wantKept: fileDiff("pkg/feature/evaluator.go", 2) + binary("ui/web/logo.png"),
+		},
+		{
+			name: "default deny list",
+			g:    NewInputGuardrails(),
+			diff: fileDiff("proto/feature/service.pb.go", 3) + fileDiff("pkg/feature/evaluator.go", 2) +
+				fileDiff("ui/web/src/service_pb.ts", 1) + fileDiff("ui/web/yarn.lock", 1) + deleted +
+				fileDiff("vendor/github.com/x/y.go", 1),
+			wantKept:     fileDiff("pkg/feature/evaluator.go", 2),
+			wantFiltered: []string{"proto/feature/service.pb.go", "ui/web/src/service_pb.ts", "ui/web/yarn.lock", "go.sum", "vendor/github.com/x/y.go"},
+		},
+		{
+			name: "include and exclude",
+			g:    restricted,
+			diff: fileDiff("pkg/feature/evaluator.go", 1) + fileDiff("pkg/internal/cache.go", 1) +
+				fileDiff("api/openapi.yaml", 1) + binary("ui/web/logo.png"),
+			wantKept:     fileDiff("pkg/feature/evaluator.go", 1) + binary("ui/web/logo.png"),
+			wantFiltered: []string{"pkg/internal/cache.go", "api/openapi.yaml"},
+		},
+		{
+			name:         "binary file",
+			g:            restricted,
+			diff:         binary("assets/logo.png") + fileDiff("ui/web/src/index.ts", 1),
+			wantKept:     fileDiff("ui/web/src/index.ts", 1),
+			wantFiltered: []string{"assets/logo.png"},
+		},
+		{
+			name: "renames are kept if either path is allowed",
+			g:    NewInputGuardrails(),
+			diff: rename("vendor/github.com/x/y.go", "pkg/y/y.go") + rename("pkg/z.go", "vendor/z.go") +
+				rename("vendor/a.go", "vendor/b/a.go"),
+			wantKept:     rename("vendor/github.com/x/y.go", "pkg/y/y.go") + rename("pkg/z.go", "vendor/z.go"),
+			wantFiltered: []string{"vendor/b/a.go"},
+		},
+		{
+			name:     "no filters",
+			g:        unfiltered,
+			diff:     fileDiff("go.sum", 1),
+			wantKept: fileDiff("go.sum", 1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, filtered := tt.g.FilterDiff(tt.diff)
+			if kept != tt.wantKept {
+				t.Errorf("FilterDiff() kept\n%s\nwant\n%s", kept, tt.wantKept)
+			}
+			if !slices.Equal(filtered, tt.wantFiltered) {
+				t.Errorf("FilterDiff() filtered %v, want %v", filtered, tt.wantFiltered)
+			}
+		})
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	appctx "github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/context"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/tokenizer"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/unidiff"
 )
 
 // Input guardrail limits based on design.md section 4.1
@@ -24,7 +26,8 @@ const (
 	// MaxDocContentBytes is the maximum size for document content (32KB)
 	MaxDocContentBytes = 32 * 1024
 
-	// MaxInputTokens is the maximum token limit for GPT-4o (with margin from 128K limit)
+	// MaxInputTokens is the token limit for models without a known context
+	// window (GPT-4o's 128K less room for the response, with margin)
 	MaxInputTokens = 100000
 
 	// MaxIssueBodyLen is the maximum length for issue body (20KB)
@@ -39,7 +42,6 @@ const (
 
 // Input validation errors
 var (
-	ErrDiffTooLarge       = errors.New("diff size exceeds maximum allowed")
 	ErrTooManyFiles       = errors.New("too many changed files")
 	ErrFileTooLarge       = errors.New("file exceeds maximum lines")
 	ErrDocTooLarge        = errors.New("document content exceeds maximum size")
@@ -48,21 +50,6 @@ var (
 	ErrPRBodyTooLarge     = errors.New("PR body exceeds maximum size")
 )
 
-// DiffFile represents a single file in a diff
-type DiffFile struct {
-	Path      string
-	LineCount int
-	Additions int    // Number of added lines
-	Deletions int    // Number of deleted lines
-	Content   string // Raw diff content for this file
-}
-
-// Diff represents a code diff with metadata
-type Diff struct {
-	TotalSize int
-	Files     []DiffFile
-}
-
 // IssueContext is an alias to context.IssueContext for validation.
 type IssueContext = appctx.IssueContext
 
@@ -78,7 +65,11 @@ type InputGuardrails struct {
 	MaxInputTokens     int
 	MaxIssueBodyLen    int
 	MaxPRBodyLen       int
-	AllowedSourcePaths []string
+	AllowedSourcePaths []string // Changed files to keep (doublestar globs); empty keeps all
+	DeniedSourcePaths  []string // Changed files to drop, even if allowed
+	Tokenizer          *tokenizer.Tokenizer
+	RedactSecrets      bool // Replace secrets in the context before prompting
+	FailOnSecrets      bool // Reject a context containing secrets instead
 }
 
 // NewInputGuardrails creates a new InputGuardrails with default limits
@@ -92,32 +83,72 @@ func NewInputGuardrails() *InputGuardrails {
 		MaxIssueBodyLen:    MaxIssueBodyLen,
 		MaxPRBodyLen:       MaxPRBodyLen,
 		AllowedSourcePaths: []string{}, // Empty means all paths allowed
+		DeniedSourcePaths:  append([]string{}, DefaultDeniedSourcePaths...),
+		Tokenizer:          tokenizer.Heuristic(),
+		RedactSecrets:      true,
 	}
 }
 
-// ValidateDiff validates the diff against size and file count limits
-func (g *InputGuardrails) ValidateDiff(diff *Diff) error {
+// ValidateDiff validates the diff against file count and line limits.
+// Changed lines (additions plus deletions) count against MaxLinesPerFile;
+// binary files have none. Its size is not checked: large diffs are
+// summarized instead (see SummarizeLargeDiff), so validate the diff
+// before summarizing it, while it still has its file headers.
+func (g *InputGuardrails) ValidateDiff(diff *unidiff.Diff) error {
 	if diff == nil {
 		return nil
 	}
 
-	if diff.TotalSize > g.MaxDiffSizeBytes {
-		return fmt.Errorf("%w: %d bytes (max %d)", ErrDiffTooLarge, diff.TotalSize, g.MaxDiffSizeBytes)
-	}
-
 	if len(diff.Files) > g.MaxChangedFiles {
 		return fmt.Errorf("%w: %d files (max %d)", ErrTooManyFiles, len(diff.Files), g.MaxChangedFiles)
 	}
 
 	for _, file := range diff.Files {
-		if file.LineCount > g.MaxLinesPerFile {
-			return fmt.Errorf("%w: %s has %d lines (max %d)", ErrFileTooLarge, file.Path, file.LineCount, g.MaxLinesPerFile)
+		if lines := file.Additions() + file.Deletions(); lines > g.MaxLinesPerFile {
+			return fmt.Errorf("%w: %s has %d lines (max %d)", ErrFileTooLarge, file.Path(), lines, g.MaxLinesPerFile)
 		}
 	}
 
 	return nil
 }
 
+// RedactContext replaces secrets in the issue and PR text sent to the LLM,
+// in place, and returns the redaction counts by kind. If FailOnSecrets is set and secrets
+// are found, the context is left unchanged and ErrSecretsDetected is returned.
+func (g *InputGuardrails) RedactContext(issue *IssueContext, pr *PRContext) (map[string]int, error) {
+	if !g.RedactSecrets && !g.FailOnSecrets {
+		return nil, nil
+	}
+
+	r := NewRedactor()
+	var fields []*string
+	if issue != nil {
+		fields = append(fields, &issue.Title, &issue.Body)
+		for i := range issue.Comments {
+			fields = append(fields, &issue.Comments[i].Body)
+		}
+	}
+	if pr != nil {
+		// The per-PR records are only used for routing; their text is combined here
+		fields = append(fields, &pr.Title, &pr.Body, &pr.Diff)
+	}
+
+	redacted := make([]string, len(fields))
+	for i, f := range fields {
+		redacted[i] = r.Redact(*f)
+	}
+	if r.Total() == 0 {
+		return nil, nil
+	}
+	if g.FailOnSecrets {
+		return r.Counts(), fmt.Errorf("%w: %s", ErrSecretsDetected, r.Summary())
+	}
+	for i, f := range fields {
+		*f = redacted[i]
+	}
+	return r.Counts(), nil
+}
+
 // ValidateDocContent validates that a document's content is within size limits
 func (g *InputGuardrails) ValidateDocContent(content string) error {
 	if len(content) > g.MaxDocContentBytes {
@@ -128,7 +159,7 @@ func (g *InputGuardrails) ValidateDocContent(content string) error {
 
 // ValidateTokenLimit checks if the combined context exceeds the token limit
 func (g *InputGuardrails) ValidateTokenLimit(prContext, docContent string) error {
-	total := EstimateTokens(prContext) + EstimateTokens(docContent)
+	total := g.CountTokens(prContext) + g.CountTokens(docContent)
 	if total > g.MaxInputTokens {
 		return fmt.Errorf("%w: %d tokens (max %d)", ErrTokenLimitExceeded, total, g.MaxInputTokens)
 	}
@@ -155,164 +186,156 @@ func (g *InputGuardrails) ValidateContext(issue *IssueContext, pr *PRContext) er
 }
 
 // SummarizeLargeDiff creates a summary for diffs exceeding the size limit.
-// Returns the original diff if it's within limits, or a summary otherwise.
+// Returns the original diff if it's within limits, or a summary otherwise:
+// every changed file with its status, line counts and relevance, then the
+// hunks of the most relevant files. The space for hunks is shared between
+// files by relevance weight (API definitions and UI strings first; tests,
+// generated code and lockfiles last or not at all), whole hunks are kept in
+// file order while they fit, and the file list notes what was left out.
 func SummarizeLargeDiff(diff string, maxSize int) string {
 	if len(diff) <= maxSize {
 		return diff
 	}
 
-	// Parse the diff to extract file information
-	parsed := ParseDiff(diff)
-
-	var summary strings.Builder
-	summary.WriteString("## Diff Summary (original too large)\n\n")
-	summary.WriteString(fmt.Sprintf("Total: %d files changed, %d bytes\n\n", len(parsed.Files), parsed.TotalSize))
-	summary.WriteString("### Changed Files:\n")
-
-	for _, f := range parsed.Files {
-		summary.WriteString(fmt.Sprintf("- %s (+%d/-%d lines)\n", f.Path, f.Additions, f.Deletions))
+	parsed := unidiff.Parse(diff)
+	files := parsed.Files
+
+	// Score files and measure their hunks
+	weights := make([]float64, len(files))
+	labels := make([]string, len(files))
+	sizes := make([]int, len(files))
+	hunks := make([][]string, len(files))
+	for i, f := range files {
+		weights[i], labels[i] = fileRelevance(f.Path())
+		for _, h := range f.Hunks {
+			hunks[i] = append(hunks[i], h.String())
+			sizes[i] += len(hunks[i][len(hunks[i])-1])
+		}
 	}
 
-	// Include truncated content of key files (prioritize non-test, non-vendor files)
-	summary.WriteString("\n### Key Changes (truncated):\n")
-	remainingBytes := maxSize - summary.Len() - 100 // Reserve space
-
-	for _, f := range parsed.Files {
-		// Skip test files and vendor
-		if isTestOrVendorFile(f.Path) {
-			continue
-		}
+	header := fmt.Sprintf("## Diff Summary (original too large)\n\nTotal: %d files changed, %d bytes\n"+
+		"Hunks are included by documentation relevance; the file list notes what was omitted.\n\n### Changed Files:\n",
+		len(files), parsed.Size)
 
-		if remainingBytes <= 0 {
-			break
+	// Reserve the file list (with room for its notes) and each section's framing
+	budget := maxSize - len(header) - len("\n### Key Changes (truncated):\n") - 100
+	for i, f := range files {
+		budget -= len(fileListLine(f, labels[i], "")) + maxNoteLen
+		if weights[i] > 0 && sizes[i] > 0 {
+			budget -= sectionOverhead(f)
 		}
-
-		// Include first portion of each file's diff
-		content := f.Content
-		if len(content) > remainingBytes/len(parsed.Files) {
-			content = content[:remainingBytes/len(parsed.Files)] + "\n...[truncated]"
+	}
+	shares := allocateBudget(weights, sizes, max(budget, 0))
+
+	var list, sections strings.Builder
+	omittedFiles, omittedHunks, cutHunks := 0, 0, 0
+	for i, f := range files {
+		note := ""
+		switch {
+		case len(hunks[i]) == 0:
+		case weights[i] == 0:
+			note = "omitted"
+			omittedFiles++
+		default:
+			content, omitted, cut := takeHunks(hunks[i], shares[i])
+			if content == "" {
+				note = "omitted"
+				omittedFiles++
+				break
+			}
+			if cut {
+				note = "first hunk truncated"
+				cutHunks++
+			}
+			if omitted > 0 {
+				note = strings.TrimPrefix(note+fmt.Sprintf(", %d of %d hunks omitted", omitted, len(hunks[i])), ", ")
+				omittedHunks += omitted
+			}
+			sections.WriteString(fmt.Sprintf("\n#### %s\n```diff\n%s```\n", f.Path(), content))
 		}
-
-		summary.WriteString(fmt.Sprintf("\n#### %s\n```diff\n%s\n```\n", f.Path, content))
-		remainingBytes -= len(content) + 50
+		list.WriteString(fileListLine(f, labels[i], note))
 	}
 
+	var summary strings.Builder
+	summary.WriteString(header)
+	summary.WriteString(list.String())
+	summary.WriteString("\n### Key Changes (truncated):\n")
+	summary.WriteString(sections.String())
+	if omittedFiles > 0 || omittedHunks > 0 || cutHunks > 0 {
+		summary.WriteString(fmt.Sprintf("\nTo fit %d bytes: %d files omitted, %d hunks omitted from included files, %d hunks truncated.\n",
+			maxSize, omittedFiles, omittedHunks, cutHunks))
+	}
 	return summary.String()
 }
 
-// isTestOrVendorFile checks if a file path is a test or vendor file
-func isTestOrVendorFile(path string) bool {
-	lowerPath := strings.ToLower(path)
-	return strings.Contains(lowerPath, "_test.") ||
-		strings.Contains(lowerPath, "/test/") ||
-		strings.Contains(lowerPath, "/vendor/") ||
-		strings.Contains(lowerPath, "/node_modules/") ||
-		strings.HasSuffix(lowerPath, ".test.ts") ||
-		strings.HasSuffix(lowerPath, ".test.tsx") ||
-		strings.HasSuffix(lowerPath, ".spec.ts") ||
-		strings.HasSuffix(lowerPath, ".spec.tsx")
-}
+// maxNoteLen bounds the relevance note appended to a file list line.
+const maxNoteLen = 48
 
-// IsAllowedPath checks if a file path is in the allowed source paths
-// If AllowedSourcePaths is empty, all paths are allowed
-func (g *InputGuardrails) IsAllowedPath(path string) bool {
-	if len(g.AllowedSourcePaths) == 0 {
-		return true
+// fileListLine returns the summary line of a changed file with its
+// relevance label and what was omitted from it.
+func fileListLine(f unidiff.File, label, note string) string {
+	if note != "" {
+		label += ", " + note
 	}
-
-	for _, allowed := range g.AllowedSourcePaths {
-		if matchPath(path, allowed) {
-			return true
-		}
-	}
-	return false
+	return fmt.Sprintf("- %s (%s; %s)\n", displayPath(f), fileChange(f), label)
 }
 
-// EstimateTokens provides a simple token estimation.
-// Rule of thumb: 1 token ≈ 4 characters for English text.
-func EstimateTokens(text string) int {
-	return len(text) / 4
+// sectionOverhead is the size of a Key Changes section without its hunks.
+func sectionOverhead(f unidiff.File) int {
+	return len(fmt.Sprintf("\n#### %s\n```diff\n```\n", f.Path())) + len("...[hunk truncated]\n...[999 of 999 hunks omitted]\n")
 }
 
-// matchPath performs simple path matching with glob-like patterns
-func matchPath(path, pattern string) bool {
-	if pattern == "" {
-		return false
+// takeHunks returns the hunks that fit in budget bytes, in order, and the
+// number left out. If not even the first hunk fits, its first lines are kept
+// and cut is set.
+func takeHunks(hunks []string, budget int) (content string, omitted int, cut bool) {
+	var sb strings.Builder
+	for i, hunk := range hunks {
+		if sb.Len()+len(hunk) <= budget {
+			sb.WriteString(hunk)
+			continue
+		}
+		if i == 0 && budget > 0 {
+			if end := strings.LastIndex(hunk[:budget], "\n"); end > 0 {
+				sb.WriteString(hunk[:end+1])
+				sb.WriteString("...[hunk truncated]\n")
+				cut = true
+				continue
+			}
+		}
+		omitted++
 	}
-
-	// Handle wildcard suffix (e.g., "proto/**")
-	if strings.HasSuffix(pattern, "**") {
-		prefix := pattern[:len(pattern)-2]
-		return strings.HasPrefix(path, prefix)
+	if omitted > 0 && sb.Len() > 0 {
+		sb.WriteString(fmt.Sprintf("...[%d of %d hunks omitted]\n", omitted, len(hunks)))
 	}
-
-	return path == pattern
+	return sb.String(), omitted, cut
 }
 
-// ParseDiff parses a unified diff and extracts file information.
-// Uses simple regex to count files and estimate line changes.
-func ParseDiff(diffContent string) *Diff {
-	if diffContent == "" {
-		return nil
+// displayPath returns the file's path, as "old -> new" for renames and copies.
+func displayPath(f unidiff.File) string {
+	if f.Status == unidiff.StatusRenamed || f.Status == unidiff.StatusCopied {
+		return f.OldPath + " -> " + f.NewPath
 	}
+	return f.Path()
+}
 
-	diff := &Diff{
-		TotalSize: len(diffContent),
-		Files:     []DiffFile{},
-	}
-
-	lines := strings.Split(diffContent, "\n")
-	var currentFile *DiffFile
-	var contentBuilder strings.Builder
-	additions, deletions := 0, 0
-
-	for _, line := range lines {
-		// Detect new file in diff (diff --git a/path b/path)
-		if strings.HasPrefix(line, "diff --git ") {
-			// Save previous file if exists
-			if currentFile != nil {
-				currentFile.LineCount = additions + deletions
-				currentFile.Additions = additions
-				currentFile.Deletions = deletions
-				currentFile.Content = contentBuilder.String()
-				diff.Files = append(diff.Files, *currentFile)
-			}
-
-			// Extract file path from "diff --git a/path b/path"
-			parts := strings.Split(line, " ")
-			if len(parts) >= 4 {
-				// Remove "a/" or "b/" prefix
-				path := strings.TrimPrefix(parts[2], "a/")
-				currentFile = &DiffFile{Path: path}
-				contentBuilder.Reset()
-				additions, deletions = 0, 0
-			}
-			continue
-		}
-
-		// Collect content and count lines
-		if currentFile != nil {
-			contentBuilder.WriteString(line)
-			contentBuilder.WriteString("\n")
-
-			if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
-				additions++
-			} else if strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---") {
-				deletions++
-			}
-		}
+// fileChange describes how a file changed, e.g. "modified, +12/-3 lines".
+func fileChange(f unidiff.File) string {
+	if f.Binary {
+		return string(f.Status) + ", binary"
 	}
+	return fmt.Sprintf("%s, +%d/-%d lines", f.Status, f.Additions(), f.Deletions())
+}
 
-	// Save last file
-	if currentFile != nil {
-		currentFile.LineCount = additions + deletions
-		currentFile.Additions = additions
-		currentFile.Deletions = deletions
-		currentFile.Content = contentBuilder.String()
-		diff.Files = append(diff.Files, *currentFile)
-	}
+// CountTokens counts the tokens of text with the model's tokenizer.
+func (g *InputGuardrails) CountTokens(text string) int {
+	return g.Tokenizer.Count(text)
+}
 
-	return diff
+// EstimateTokens estimates the tokens of text without a model vocabulary.
+// See tokenizer.Estimate.
+func EstimateTokens(text string) int {
+	return tokenizer.Estimate(text)
 }
 
 // SummarizeDiff creates a concise summary of diff for Phase 1.
@@ -322,19 +345,27 @@ func SummarizeDiff(diffContent string) string {
 		return ""
 	}
 
-	parsed := ParseDiff(diffContent)
-	if parsed == nil || len(parsed.Files) == 0 {
+	parsed := unidiff.Parse(diffContent)
+	if len(parsed.Files) == 0 {
 		return ""
 	}
 
 	var sb strings.Builder
 	sb.WriteString("Changed files:\n")
 	for _, f := range parsed.Files {
-		changeType := "modified"
-		if f.LineCount > 100 {
+		changeType := string(f.Status)
+		lines := f.Additions() + f.Deletions()
+		switch {
+		case f.Binary:
+			changeType += ", binary"
+		case f.Status == unidiff.StatusModified && lines > 100:
 			changeType = "heavily modified"
 		}
-		sb.WriteString(fmt.Sprintf("- %s (%s, ~%d lines)\n", f.Path, changeType, f.LineCount))
+		if f.Binary {
+			sb.WriteString(fmt.Sprintf("- %s (%s)\n", displayPath(f), changeType))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("- %s (%s, ~%d lines)\n", displayPath(f), changeType, lines))
 	}
 
 	return sb.String()
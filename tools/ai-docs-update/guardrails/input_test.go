@@ -0,0 +1,252 @@
+package guardrails
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/unidiff"
+)
+
+// fileDiff returns the git diff of a modified file with one hunk per entry
+// of hunkLines, each adding that many lines.
+func fileDiff(path string, hunkLines ...int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "diff --git a/%s b/%s\nindex 1111111..2222222 100644\n--- a/%s\n+++ b/%s\n", path, path, path, path)
+	start := 1
+	for _, n := range hunkLines {
+		fmt.Fprintf(&sb, "@@ -%d,1 +%d,%d @@\n context\n", start, start, n+1)
+		for i := 0; i < n; i++ {
+			fmt.Fprintf(&sb, "+line %d of %s\n", i, path)
+		}
+		start += 100
+	}
+	return sb.String()
+}
+
+func TestFileRelevance(t *testing.T) {
+	tests := []struct {
+		path      string
+		wantLabel string
+		wantZero  bool
+	}{
+		{path: "go.sum", wantLabel: "lockfile", wantZero: true},
+		{path: "ui/web/pnpm-lock.yaml", wantLabel: "lockfile", wantZero: true},
+		{path: "vendor/github.com/x/y.go", wantLabel: "vendored", wantZero: true},
+		{path: "proto/feature/service.pb.go", wantLabel: "generated code", wantZero: true},
+		{path: "pkg/feature/mock/client.go", wantLabel: "mock", wantZero: true},
+		{path: "pkg/feature/evaluator_test.go", wantLabel: "test"},
+		{path: "proto/feature/service.proto", wantLabel: "API definition"},
+		{path: "ui/web/src/@locales/en.json", wantLabel: "UI strings"},
+		{path: "manifests/bucketeer/values.yaml", wantLabel: "config schema"},
+		{path: "pkg/bucketeer/client.go", wantLabel: "SDK public surface"},
+		{path: "docs/sdk/android.md", wantLabel: "docs"},
+		{path: "ui/web/src/pages/flags.tsx", wantLabel: "dashboard UI"},
+		{path: "pkg/feature/evaluator.go", wantLabel: defaultRelevanceLabel},
+	}
+	for _, tt := range tests {
+		weight, label := fileRelevance(tt.path)
+		if label != tt.wantLabel || (weight == 0) != tt.wantZero {
+			t.Errorf("fileRelevance(%q) = %v, %q; want label %q (zero weight %v)", tt.path, weight, label, tt.wantLabel, tt.wantZero)
+		}
+	}
+
+	// Documentation-relevant files outrank plain source, which outranks tests
+	api, _ := fileRelevance("proto/feature/service.proto")
+	src, _ := fileRelevance("pkg/feature/evaluator.go")
+	test, _ := fileRelevance("pkg/feature/evaluator_test.go")
+	if !(api > src && src > test) {
+		t.Errorf("weights: API definition %v, source %v, test %v; want decreasing", api, src, test)
+	}
+}
+
+func TestAllocateBudget(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []float64
+		sizes   []int
+		budget  int
+		want    []int
+	}{
+		{
+			name:    "everything fits",
+			weights: []float64{1, 1},
+			sizes:   []int{100, 200},
+			budget:  1000,
+			want:    []int{100, 200},
+		},
+		{
+			name:    "split by weight",
+			weights: []float64{3, 1},
+			sizes:   []int{1000, 1000},
+			budget:  400,
+			want:    []int{300, 100},
+		},
+		{
+			name:    "unused share is split again",
+			weights: []float64{1, 1, 2},
+			sizes:   []int{10, 1000, 1000},
+			budget:  410,
+			want:    []int{10, 133, 266},
+		},
+		{
+			name:    "zero weight and empty files get nothing",
+			weights: []float64{0, 5, 1},
+			sizes:   []int{1000, 0, 1000},
+			budget:  300,
+			want:    []int{0, 0, 300},
+		},
+		{
+			name:    "no budget",
+			weights: []float64{1},
+			sizes:   []int{100},
+			budget:  0,
+			want:    []int{0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := allocateBudget(tt.weights, tt.sizes, tt.budget); !slices.Equal(got, tt.want) {
+				t.Errorf("allocateBudget() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTakeHunks(t *testing.T) {
+	hunks := []string{
+		"@@ -1 +1,2 @@\n a\n+b\n",       // 20 bytes
+		"@@ -10 +11,2 @@\n c\n+d\n",     // 22 bytes
+		"@@ -20 +22,3 @@\n e\n+f\n+g\n", // 25 bytes
+	}
+	tests := []struct {
+		name        string
+		budget      int
+		want        string
+		wantOmitted int
+		wantCut     bool
+	}{
+		{
+			name:   "all fit",
+			budget: 100,
+			want:   hunks[0] + hunks[1] + hunks[2],
+		},
+		{
+			name:        "later hunks omitted",
+			budget:      45,
+			want:        hunks[0] + hunks[1] + "...[1 of 3 hunks omitted]\n",
+			wantOmitted: 1,
+		},
+		{
+			name:        "only the first hunk fits",
+			budget:      41,
+			want:        hunks[0] + "...[2 of 3 hunks omitted]\n",
+			wantOmitted: 2,
+		},
+		{
+			name:        "first hunk truncated",
+			budget:      18,
+			want:        "@@ -1 +1,2 @@\n a\n...[hunk truncated]\n" + "...[2 of 3 hunks omitted]\n",
+			wantOmitted: 2,
+			wantCut:     true,
+		},
+		{
+			name:        "nothing fits",
+			budget:      5,
+			wantOmitted: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, omitted, cut := takeHunks(hunks, tt.budget)
+			if got != tt.want || omitted != tt.wantOmitted || cut != tt.wantCut {
+				t.Errorf("takeHunks() = %q, %d, %v; want %q, %d, %v", got, omitted, cut, tt.want, tt.wantOmitted, tt.wantCut)
+			}
+		})
+	}
+}
+
+func TestSummarizeLargeDiff(t *testing.T) {
+	diff := fileDiff("proto/feature/service.proto", 5) +
+		fileDiff("pkg/feature/evaluator.go", 10, 10, 10, 10, 10, 10, 10, 10) +
+		fileDiff("pkg/feature/rules.go", 400) +
+		fileDiff("pkg/feature/evaluator_test.go", 300) +
+		fileDiff("go.sum", 2) +
+		"diff --git a/ui/web/logo.png b/ui/web/logo.png\nindex 1111111..2222222 100644\nBinary files a/ui/web/logo.png and b/ui/web/logo.png differ\n"
+
+	if got := SummarizeLargeDiff(diff, len(diff)); got != diff {
+		t.Errorf("SummarizeLargeDiff() changed a diff within the limit")
+	}
+
+	const maxSize = 4096
+	got := SummarizeLargeDiff(diff, maxSize)
+	if len(got) > maxSize {
+		t.Errorf("summary is %d bytes, want at most %d", len(got), maxSize)
+	}
+
+	wantLines := []string{
+		"Total: 6 files changed, ",
+		"- proto/feature/service.proto (modified, +5/-0 lines; API definition)\n",
+		"- pkg/feature/evaluator.go (modified, +80/-0 lines; source, 5 of 8 hunks omitted)\n",
+		"- pkg/feature/rules.go (modified, +400/-0 lines; source, first hunk truncated)\n",
+		"- pkg/feature/evaluator_test.go (modified, +300/-0 lines; test, first hunk truncated)\n",
+		"- go.sum (modified, +2/-0 lines; lockfile, omitted)\n",
+		"- ui/web/logo.png (modified, binary; dashboard UI)\n",
+		"#### proto/feature/service.proto\n```diff\n@@ -1,1 +1,6 @@\n",
+		"...[5 of 8 hunks omitted]\n",
+		"...[hunk truncated]\n",
+		"To fit 4096 bytes: 1 files omitted, 5 hunks omitted from included files, 2 hunks truncated.\n",
+	}
+	for _, want := range wantLines {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "#### go.sum") {
+		t.Errorf("summary includes the lockfile's hunks:\n%s", got)
+	}
+}
+
+func TestValidateDiff(t *testing.T) {
+	g := NewInputGuardrails()
+	g.MaxChangedFiles = 2
+	g.MaxLinesPerFile = 50
+	tests := []struct {
+		name    string
+		diff    string
+		wantErr error
+	}{
+		{name: "within limits", diff: fileDiff("a.go", 10) + fileDiff("b.go", 50)},
+		{name: "too many files", diff: fileDiff("a.go", 1) + fileDiff("b.go", 1) + fileDiff("c.go", 1), wantErr: ErrTooManyFiles},
+		{name: "file too large", diff: fileDiff("a.go", 30, 30), wantErr: ErrFileTooLarge},
+		// Size is not checked: large diffs are summarized instead
+		{name: "large diff", diff: fileDiff("a.go", 40, 10) + strings.Repeat(" ", MaxDiffSizeBytes)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := g.ValidateDiff(unidiff.Parse(tt.diff)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateDiff() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSummarizeDiff(t *testing.T) {
+	diff := fileDiff("pkg/feature/evaluator.go", 120) +
+		"diff --git a/old.go b/new.go\nsimilarity index 100%\nrename from old.go\nrename to new.go\n" +
+		"diff --git a/ui/web/logo.png b/ui/web/logo.png\nindex 1111111..2222222 100644\nBinary files a/ui/web/logo.png and b/ui/web/logo.png differ\n"
+	want := "Changed files:\n" +
+		"- pkg/feature/evaluator.go (heavily modified, ~120 lines)\n" +
+		"- old.go -> new.go (renamed, ~0 lines)\n" +
+		"- ui/web/logo.png (modified, binary)\n"
+	if got := SummarizeDiff(diff); got != want {
+		t.Errorf("SummarizeDiff() =\n%s\nwant\n%s", got, want)
+	}
+
+	// A summarized diff has no file headers left to list
+	if got := SummarizeDiff(SummarizeLargeDiff(diff, 200)); got != "" {
+		t.Errorf("SummarizeDiff() of a summarized diff = %q, want \"\"", got)
+	}
+}
@@ -49,17 +49,22 @@ func (g *OutputGuardrails) Validate(output string) error {
 	}
 
 	// 2. Extract document content
-	content := ExtractDocumentContent(output)
-	if content == "" {
+	return g.ValidateContent(ExtractDocumentContent(output))
+}
+
+// ValidateContent validates document content that has already been extracted
+// from the <updated_document> tags (or read from an existing file)
+func (g *OutputGuardrails) ValidateContent(content string) error {
+	if strings.TrimSpace(content) == "" {
 		return ErrEmptyContent
 	}
 
-	// 3. Check size limit
+	// Check size limit
 	if len(content) > g.MaxOutputSize {
 		return fmt.Errorf("%w: %d bytes (max %d)", ErrOutputTooLarge, len(content), g.MaxOutputSize)
 	}
 
-	// 4. Basic markdown syntax validation
+	// Basic markdown syntax validation
 	if err := g.validateMarkdown(content); err != nil {
 		return err
 	}
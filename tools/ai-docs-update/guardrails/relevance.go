@@ -0,0 +1,91 @@
+package guardrails
+
+// relevanceRule weights the changed files matching any of its patterns
+// (doublestar globs) by how much they tell about documentation-worthy changes.
+type relevanceRule struct {
+	label    string
+	weight   float64 // 0 leaves the file's hunks out of large-diff summaries
+	patterns []string
+}
+
+// relevanceRules are matched in order; the first match wins. Files matching
+// no rule are weighted as defaultRelevance. Lockfiles, vendored trees and
+// generated code are filtered out by default (DefaultDeniedSourcePaths) but
+// still weighted for when the filters are relaxed.
+var relevanceRules = []relevanceRule{
+	{"lockfile", 0, lockfilePatterns},
+	{"vendored", 0, vendoredPatterns},
+	{"generated code", 0, generatedPatterns},
+	{"mock", 0, []string{"**/{mock,mocks,__mocks__}/**", "**/*_mock.go", "**/mock_*.go", "**/__snapshots__/**"}},
+	{"test", 0.5, []string{
+		"**/*_test.*", "**/*.{test,spec}.*", "**/{test,tests,__tests__,testdata,e2e}/**", "**/*Test.{kt,java}", "**/*Tests.swift",
+	}},
+	{"API definition", 10, []string{
+		"**/*.proto", "**/*{openapi,swagger}*.{yaml,yml,json}", "**/*.graphql",
+	}},
+	{"UI strings", 8, []string{"**/{locale,locales,@locales,i18n,lang,translations}/**"}},
+	{"config schema", 7, []string{
+		"**/*.schema.{json,yaml,yml}", "**/values*.{yaml,yml}", "**/Chart.yaml", "**/{config,settings}.{yaml,yml,json,toml}",
+	}},
+	{"SDK public surface", 7, []string{
+		"**/*.d.ts", "**/src/index.{ts,tsx,js}", "**/lib/*.dart", "**/Sources/**/*.swift", "**/src/main/**/*.{kt,java}", "pkg/bucketeer/**/*.go",
+	}},
+	{"docs", 6, []string{"**/*.{md,mdx}"}},
+	{"dashboard UI", 5, []string{"ui/**"}},
+}
+
+const (
+	defaultRelevance      = 3
+	defaultRelevanceLabel = "source"
+)
+
+// fileRelevance returns the weight and label of the first rule matching path.
+func fileRelevance(path string) (float64, string) {
+	for _, r := range relevanceRules {
+		if matchAny(r.patterns, path) {
+			return r.weight, r.label
+		}
+	}
+	return defaultRelevance, defaultRelevanceLabel
+}
+
+// allocateBudget splits budget bytes between files in proportion to their
+// weights. A file needing less than its share gets what it needs and the
+// rest is split again among the others. sizes are the bytes each file needs.
+func allocateBudget(weights []float64, sizes []int, budget int) []int {
+	shares := make([]int, len(weights))
+	var active []int
+	for i, w := range weights {
+		if w > 0 && sizes[i] > 0 {
+			active = append(active, i)
+		}
+	}
+
+	for len(active) > 0 && budget > 0 {
+		var total float64
+		for _, i := range active {
+			total += weights[i]
+		}
+
+		var rest []int
+		used := 0
+		for _, i := range active {
+			if share := int(float64(budget) * weights[i] / total); sizes[i] <= share {
+				shares[i] = sizes[i]
+				used += sizes[i]
+			} else {
+				rest = append(rest, i)
+			}
+		}
+		if used == 0 {
+			// Nobody fits in their share: everyone gets their share
+			for _, i := range active {
+				shares[i] = int(float64(budget) * weights[i] / total)
+			}
+			break
+		}
+		budget -= used
+		active = rest
+	}
+	return shares
+}
@@ -0,0 +1,234 @@
+package guardrails
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ErrSecretsDetected indicates secrets in the issue or PR context when
+// redaction is configured to fail closed.
+var ErrSecretsDetected = errors.New("secrets detected in issue or PR context")
+
+// secretPattern detects one kind of secret. If the pattern has groups, the
+// first matching one is the secret (the key name around it is kept). If
+// minEntropy is set, the secret must also look random (see looksRandom); if
+// check is set, the secret must pass it. Secrets matching except are left alone.
+type secretPattern struct {
+	kind       string
+	pattern    *regexp.Regexp
+	minEntropy float64
+	check      func(string) bool
+	except     *regexp.Regexp
+}
+
+// secretPatterns are applied in order; text replaced by an earlier pattern is
+// not matched again. Vendor prefixes come before the generic key=value checks.
+var secretPatterns = []secretPattern{
+	{kind: "private_key", pattern: regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY(?: BLOCK)?-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY(?: BLOCK)?-----`)},
+	{kind: "aws_access_key", pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{kind: "github_token", pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`)},
+	{kind: "anthropic_api_key", pattern: regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`)},
+	{kind: "openai_api_key", pattern: regexp.MustCompile(`\bsk-(?:proj-|svcacct-|admin-)?[A-Za-z0-9_-]{20,}`)},
+	{kind: "slack_token", pattern: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{kind: "google_api_key", pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{kind: "stripe_key", pattern: regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{20,}\b`)},
+	{kind: "jwt", pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{kind: "url_credentials", pattern: regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]+:([^/\s:@]{3,})@`)},
+	// Bucketeer API keys have no prefix: SDK builders (.apiKey("...")), the
+	// code-refs --apiKey flag, BUCKETEER_API_KEY variables and the REST
+	// authorization header
+	{kind: "bucketeer_api_key", pattern: regexp.MustCompile(`(?i)(?:bucketeer[_-]?api[_-]?key|bkt[_-]?api[_-]?key|\bapi[_-]?key)["']?\s*(?:[:=(,]|\s)\s*["']?([A-Za-z0-9_-]{20,})`), minEntropy: 3},
+	{kind: "authorization_header", pattern: regexp.MustCompile(`(?i)\bauthorization["']?\s*[:=]\s*["']?(?:bearer\s+|basic\s+|token\s+)?([A-Za-z0-9._~+/=-]{16,})`), minEntropy: 3},
+	// Passwords are often words, with no digits to tell them from identifiers
+	{kind: "password", pattern: regexp.MustCompile(`(?i)\b[\w.-]*(?:passw(?:or)?d|passphrase|\bpwd)[\w.-]*["']?\s*[:=]\s*(?:"([^"\s]{8,})"|'([^'\s]{8,})'|([^\s"'\x60,;(){}<>]{8,}))`),
+		check: looksLikePassword},
+	{kind: "secret_assignment", pattern: regexp.MustCompile(`(?i)\b[\w.-]*(?:secret|token|passw(?:or)?d|credential|private[_-]?key|access[_-]?key|client[_-]?key)[\w.-]*["']?\s*[:=]\s*(?:"([^"\s]{12,})"|'([^'\s]{12,})'|([A-Za-z0-9+/_.=-]{12,}))`), minEntropy: 3},
+	// Long random string literals with no telling key name
+	{kind: "high_entropy_string", pattern: regexp.MustCompile(`["']([A-Za-z0-9+/_=-]{32,})["']`), minEntropy: 4.5,
+		except: regexp.MustCompile(`^sha(?:1|256|384|512)-`)}, // subresource integrity hashes
+}
+
+// Redactor replaces secrets with placeholders such as [REDACTED_GITHUB_TOKEN_1].
+// Placeholders are stable: the same secret gets the same placeholder in every
+// text a Redactor sees, so the LLM can still tell two secrets apart.
+type Redactor struct {
+	placeholders map[string]string // secret -> placeholder
+	next         map[string]int    // kind -> last placeholder number
+	counts       map[string]int    // kind -> occurrences redacted
+}
+
+// NewRedactor creates a Redactor with no secrets seen.
+func NewRedactor() *Redactor {
+	return &Redactor{
+		placeholders: make(map[string]string),
+		next:         make(map[string]int),
+		counts:       make(map[string]int),
+	}
+}
+
+// Redact returns text with every detected secret replaced.
+func (r *Redactor) Redact(text string) string {
+	for _, p := range secretPatterns {
+		text = r.redactPattern(text, p)
+	}
+	return text
+}
+
+func (r *Redactor) redactPattern(text string, p secretPattern) string {
+	matches := p.pattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		for g := 1; 2*g < len(m); g++ {
+			if m[2*g] >= 0 {
+				start, end = m[2*g], m[2*g+1]
+				break
+			}
+		}
+		secret := text[start:end]
+		if p.minEntropy > 0 && !looksRandom(secret, p.minEntropy) {
+			continue
+		}
+		if p.check != nil && !p.check(secret) {
+			continue
+		}
+		if p.except != nil && p.except.MatchString(secret) {
+			continue
+		}
+		sb.WriteString(text[last:start])
+		sb.WriteString(r.placeholder(p.kind, secret))
+		last = end
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// placeholder returns the placeholder of secret, numbering new secrets per kind.
+func (r *Redactor) placeholder(kind, secret string) string {
+	r.counts[kind]++
+	if ph, ok := r.placeholders[secret]; ok {
+		return ph
+	}
+	r.next[kind]++
+	ph := fmt.Sprintf("[REDACTED_%s_%d]", strings.ToUpper(kind), r.next[kind])
+	r.placeholders[secret] = ph
+	return ph
+}
+
+// Counts returns the number of redacted occurrences by kind.
+func (r *Redactor) Counts() map[string]int {
+	return maps.Clone(r.counts)
+}
+
+// Total returns the number of redacted occurrences.
+func (r *Redactor) Total() int {
+	total := 0
+	for _, n := range r.counts {
+		total += n
+	}
+	return total
+}
+
+// Summary returns the counts as FormatRedactions does.
+func (r *Redactor) Summary() string {
+	return FormatRedactions(r.counts)
+}
+
+// FormatRedactions returns redaction counts as "kind: n" pairs, sorted by kind.
+func FormatRedactions(counts map[string]int) string {
+	parts := make([]string, 0, len(counts))
+	for _, kind := range slices.Sorted(maps.Keys(counts)) {
+		parts = append(parts, fmt.Sprintf("%s: %d", kind, counts[kind]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// looksRandom reports whether s is likely a generated secret rather than an
+// identifier, placeholder or prose: its Shannon entropy is at least
+// minEntropy bits per character, it mixes letters and digits, it is not a
+// dotted identifier (pkg.Func) and it has no placeholder wording.
+func looksRandom(s string, minEntropy float64) bool {
+	if shannonEntropy(s) < minEntropy || identifierPath.MatchString(s) {
+		return false
+	}
+	var letter, digit bool
+	for _, c := range s {
+		letter = letter || unicode.IsLetter(c)
+		digit = digit || unicode.IsDigit(c)
+	}
+	if !letter || !digit {
+		return false
+	}
+	return !hasPlaceholderWord(s)
+}
+
+// looksLikePassword reports whether s, assigned to a password key, is likely a
+// real password rather than a type, an identifier, a variable reference or a
+// placeholder. Unlike looksRandom it accepts plain words such as
+// "correcthorsebatterystaple".
+func looksLikePassword(s string) bool {
+	if shannonEntropy(s) < 2.5 || identifierPath.MatchString(s) || camelCaseIdentifier.MatchString(s) || constantName.MatchString(s) {
+		return false
+	}
+	if strings.ContainsAny(s[:1], "$%&*<{[") || strings.Contains(s, "{{") {
+		return false // variable references, templates and masked values
+	}
+	if slices.Contains(passwordValueWords, strings.ToLower(strings.TrimRight(s, "?!"))) {
+		return false
+	}
+	return !hasPlaceholderWord(s)
+}
+
+// passwordValueWords are values of password keys that are not passwords:
+// types, schema keywords and field states.
+var passwordValueWords = []string{
+	"undefined", "optional", "required", "nullable", "readonly", "password",
+	"sensitive", "disabled", "enabled", "generated", "encrypted", "redacted",
+}
+
+// hasPlaceholderWord reports whether s contains placeholder wording.
+func hasPlaceholderWord(s string) bool {
+	l := strings.ToLower(s)
+	for _, word := range []string{"your", "example", "xxxx", "placeholder", "redacted", "changeme", "dummy"} {
+		if strings.Contains(l, word) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	identifierPath      = regexp.MustCompile(`^[A-Za-z_]\w*(?:\.[A-Za-z_]\w*)+$`)
+	camelCaseIdentifier = regexp.MustCompile(`^_?[A-Za-z][a-z]+(?:[A-Z][a-z]*)+$`)
+	constantName        = regexp.MustCompile(`^[A-Z][A-Z0-9]*(?:_[A-Z0-9]+)+$`)
+)
+
+// shannonEntropy returns the entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	freq := make(map[rune]int)
+	n := 0
+	for _, c := range s {
+		freq[c]++
+		n++
+	}
+	var h float64
+	for _, count := range freq {
+		p := float64(count) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
@@ -0,0 +1,84 @@
+// Package llm defines the provider-agnostic interface used by the identify and generate phases.
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+// Message roles.
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// ChatMessage represents a message in a chat completion request.
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Request is a single chat completion request.
+type Request struct {
+	Messages []ChatMessage
+	// JSONResponse asks for a JSON object response from providers with a
+	// native JSON mode (see Provider.SupportsJSONMode); others ignore it, so
+	// callers add JSONOnlyInstruction to the prompt instead. Either way,
+	// callers must still parse defensively.
+	JSONResponse bool
+}
+
+// JSONOnlyInstruction asks for JSON output in the prompt, for providers
+// without a native JSON mode.
+const JSONOnlyInstruction = "Respond with a single JSON object only. Do not wrap it in code fences or add any other text."
+
+// Usage holds token usage reported by a provider.
+type Usage struct {
+	Requests         int `json:"requests"`
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// TotalTokens returns prompt plus completion tokens.
+func (u Usage) TotalTokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// Provider is a chat completion backend.
+type Provider interface {
+	// Name returns the provider identifier (e.g. "openai").
+	Name() string
+	// Model returns the model used for completions.
+	Model() string
+	// ChatCompletion sends the messages and returns the assistant's text.
+	ChatCompletion(ctx context.Context, req Request) (string, error)
+	// SupportsJSONMode reports whether JSONResponse is enforced by the API
+	// rather than by prompt instructions.
+	SupportsJSONMode() bool
+	// Usage returns the cumulative token usage since the provider was created.
+	Usage() Usage
+}
+
+// UsageTracker accumulates usage across concurrent requests.
+// Providers embed it to implement Provider.Usage.
+type UsageTracker struct {
+	mu    sync.Mutex
+	usage Usage
+}
+
+// Record adds the token counts of one completed request.
+func (t *UsageTracker) Record(promptTokens, completionTokens int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.usage.Requests++
+	t.usage.PromptTokens += promptTokens
+	t.usage.CompletionTokens += completionTokens
+}
+
+// Usage returns the accumulated usage.
+func (t *UsageTracker) Usage() Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.usage
+}
@@ -0,0 +1,175 @@
+package llmtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/guardrails"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/openai"
+)
+
+// Matcher decides whether a rule applies to a request.
+type Matcher func(Request) bool
+
+// Any matches every request.
+func Any() Matcher {
+	return func(Request) bool { return true }
+}
+
+// PromptContains matches requests whose user messages contain substr.
+func PromptContains(substr string) Matcher {
+	return func(r Request) bool { return strings.Contains(r.Prompt(), substr) }
+}
+
+// SystemContains matches requests whose system messages contain substr.
+func SystemContains(substr string) Matcher {
+	return func(r Request) bool { return strings.Contains(r.System(), substr) }
+}
+
+// IdentifyPhase matches Phase 1 (document identification) requests.
+func IdentifyPhase() Matcher {
+	return SystemContains("documentation analyst")
+}
+
+// GeneratePhase matches Phase 2 (update generation) requests.
+func GeneratePhase() Matcher {
+	return SystemContains("documentation updater")
+}
+
+// DocPath matches Phase 2 requests for the given doc path.
+func DocPath(path string) Matcher {
+	return All(GeneratePhase(), PromptContains("File: "+path+"\n"))
+}
+
+// All matches when every matcher matches.
+func All(matchers ...Matcher) Matcher {
+	return func(r Request) bool {
+		for _, m := range matchers {
+			if !m(r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Reply writes a scripted response.
+type Reply func(w http.ResponseWriter, r *http.Request, req Request)
+
+// Text replies with content as the assistant message.
+func Text(content string) Reply {
+	return completion(content, "stop")
+}
+
+// JSON replies with v marshaled as the assistant message.
+func JSON(v any) Reply {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("llmtest: failed to marshal JSON reply: %v", err))
+	}
+	return Text(string(data))
+}
+
+// Identify replies with a Phase 1 identification response.
+func Identify(resp openai.IdentifyResponse) Reply {
+	return JSON(resp)
+}
+
+// NoUpdate replies with a Phase 1 response that needs no update.
+func NoUpdate(reason string) Reply {
+	return Identify(openai.IdentifyResponse{NeedsUpdate: false, Reason: reason})
+}
+
+// UpdatedDocument replies with body wrapped in <updated_document> tags.
+func UpdatedDocument(body string) Reply {
+	return Text(guardrails.OpenTag + "\n" + body + "\n" + guardrails.CloseTag)
+}
+
+// MissingTags replies with body but without <updated_document> tags.
+func MissingTags(body string) Reply {
+	return Text(body)
+}
+
+// Truncated replies with the first n bytes of an updated document and
+// finish_reason "length", as when the model hits its token limit.
+func Truncated(body string, n int) Reply {
+	content := guardrails.OpenTag + "\n" + body + "\n" + guardrails.CloseTag
+	if n < len(content) {
+		content = content[:n]
+	}
+	return completion(content, "length")
+}
+
+// Status replies with an HTTP error status (e.g. 429, 500) and an API error body.
+func Status(code int) Reply {
+	return func(w http.ResponseWriter, _ *http.Request, _ Request) {
+		if code == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "0")
+		}
+		writeError(w, code, http.StatusText(code))
+	}
+}
+
+// Timeout delays the response by d, or until the client gives up.
+// Use a delay longer than the client timeout to simulate a hung request.
+func Timeout(d time.Duration) Reply {
+	return Delay(d, Status(http.StatusGatewayTimeout))
+}
+
+// Delay waits for d (or until the client disconnects) before sending next.
+func Delay(d time.Duration, next Reply) Reply {
+	return func(w http.ResponseWriter, r *http.Request, req Request) {
+		select {
+		case <-time.After(d):
+			next(w, r, req)
+		case <-r.Context().Done():
+		}
+	}
+}
+
+// completion builds a chat completion reply.
+func completion(content, finishReason string) Reply {
+	return func(w http.ResponseWriter, _ *http.Request, req Request) {
+		model := req.Model
+		if model == "" {
+			model = openai.DefaultModel
+		}
+		resp := map[string]any{
+			"id":      "chatcmpl-llmtest",
+			"object":  "chat.completion",
+			"created": time.Now().Unix(),
+			"model":   model,
+			"choices": []map[string]any{{
+				"index":         0,
+				"message":       map[string]string{"role": "assistant", "content": content},
+				"finish_reason": finishReason,
+			}},
+			"usage": map[string]int{
+				"prompt_tokens":     guardrails.EstimateTokens(req.System() + req.Prompt()),
+				"completion_tokens": guardrails.EstimateTokens(content),
+				"total_tokens":      guardrails.EstimateTokens(req.System() + req.Prompt() + content),
+			},
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// writeError writes an OpenAI-style error response.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{
+		"error": map[string]any{
+			"message": message,
+			"type":    "llmtest_error",
+		},
+	})
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
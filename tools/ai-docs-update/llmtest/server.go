@@ -0,0 +1,183 @@
+// Package llmtest provides an in-process, OpenAI-compatible fake server for integration tests.
+//
+// A Server is scripted with rules: each rule pairs a Matcher with a sequence of
+// Replies. Requests are matched against rules in the order they were added; the
+// first matching rule answers with its next reply (the last reply repeats).
+// Point the code under test at the server via OPENAI_API_BASE or
+// openai.WithAPIBase(server.APIBase()).
+//
+//	srv := llmtest.NewServer()
+//	defer srv.Close()
+//	srv.Handle(llmtest.IdentifyPhase(), llmtest.Status(429), llmtest.Identify(resp))
+//	srv.Handle(llmtest.DocPath("feature-flags/segments.mdx"), llmtest.UpdatedDocument(body))
+package llmtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/llm"
+)
+
+// chatCompletionsPath is the endpoint served by the fake server.
+const chatCompletionsPath = "/v1/chat/completions"
+
+// Request is a chat completion request received by the server.
+type Request struct {
+	Model          string            `json:"model"`
+	Messages       []llm.ChatMessage `json:"messages"`
+	ResponseFormat *struct {
+		Type string `json:"type"`
+	} `json:"response_format,omitempty"`
+}
+
+// JSONMode reports whether the request asked for a JSON object response.
+func (r Request) JSONMode() bool {
+	return r.ResponseFormat != nil && r.ResponseFormat.Type == "json_object"
+}
+
+// System returns the concatenated system messages.
+func (r Request) System() string {
+	return r.contentFor(llm.RoleSystem)
+}
+
+// Prompt returns the concatenated user messages.
+func (r Request) Prompt() string {
+	return r.contentFor(llm.RoleUser)
+}
+
+func (r Request) contentFor(role string) string {
+	var s string
+	for _, m := range r.Messages {
+		if m.Role == role {
+			s += m.Content
+		}
+	}
+	return s
+}
+
+// rule is a matcher with its scripted replies.
+type rule struct {
+	match   Matcher
+	replies []Reply
+	next    int
+}
+
+// Server is a scriptable fake chat completions server.
+type Server struct {
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	rules    []*rule
+	requests []Request
+}
+
+// NewServer starts a fake server on a random localhost port.
+func NewServer() *Server {
+	s := &Server{}
+	mux := http.NewServeMux()
+	mux.HandleFunc(chatCompletionsPath, s.handleChatCompletions)
+	s.srv = httptest.NewServer(mux)
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// URL returns the server root URL.
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+// APIBase returns the value for OPENAI_API_BASE / openai.WithAPIBase.
+func (s *Server) APIBase() string {
+	return s.srv.URL + "/v1"
+}
+
+// Handle adds a rule. Replies are returned in order; the last one repeats.
+func (s *Server) Handle(match Matcher, replies ...Reply) {
+	if len(replies) == 0 {
+		panic("llmtest: Handle requires at least one reply")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = append(s.rules, &rule{match: match, replies: replies})
+}
+
+// Reset removes all rules and recorded requests.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = nil
+	s.requests = nil
+}
+
+// Requests returns a copy of all requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestCount returns the number of requests received that match m.
+func (s *Server) RequestCount(m Matcher) int {
+	count := 0
+	for _, req := range s.Requests() {
+		if m(req) {
+			count++
+		}
+	}
+	return count
+}
+
+// handleChatCompletions serves POST /v1/chat/completions.
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to read body: %v", err))
+		return
+	}
+	var req Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON: %v", err))
+		return
+	}
+
+	reply := s.nextReply(req)
+	if reply == nil {
+		// 400 is not retried by SDK clients, so a missing rule fails fast
+		writeError(w, http.StatusBadRequest, "llmtest: no rule matched the request")
+		return
+	}
+	reply(w, r, req)
+}
+
+// nextReply records the request and returns the reply of the first matching rule.
+func (s *Server) nextReply(req Request) Reply {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, req)
+	for _, rl := range s.rules {
+		if !rl.match(req) {
+			continue
+		}
+		reply := rl.replies[rl.next]
+		if rl.next < len(rl.replies)-1 {
+			rl.next++
+		}
+		return reply
+	}
+	return nil
+}
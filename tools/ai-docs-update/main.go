@@ -4,150 +4,191 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/anthropic"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/cassette"
+	appconfig "github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/config"
 	appctx "github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/context"
 	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/docs"
 	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/file"
 	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/glossary"
 	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/guardrails"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/llm"
 	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/openai"
-	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/styleguide"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/report"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/sidebar"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/tokenizer"
 )
 
 const appTimeout = 5 * time.Minute
 
 func main() {
-	var (
-		issueTitleFile = flag.String("issue-title-file", "", "Path to issue title file")
-		issueBodyFile  = flag.String("issue-body-file", "", "Path to issue body file")
-		prTitleFile    = flag.String("pr-title-file", "", "Path to PR title file")
-		prBodyFile     = flag.String("pr-body-file", "", "Path to PR body file")
-		diffFile       = flag.String("diff-file", "", "Path to diff file")
-		glossaryFile   = flag.String("glossary-file", "", "Path to vocabulary.json file")
-		docsDir        = flag.String("docs-dir", "docs", "Path to docs directory")
-		excludeDirs    = flag.String("exclude-dirs", "", "Comma-separated directories to exclude (default: changelog,contribution-guide)")
-		excludeFiles   = flag.String("exclude-files", "", "Comma-separated files to exclude (default: bucketeer-docs.mdx)")
-	)
+	if len(os.Args) > 1 {
+		if cmd := findCommand(os.Args[1]); cmd != nil {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				log.Fatalf("ERROR: %v", err)
+			}
+			return
+		}
+	}
+
+	cfg := &config{}
+	fs := flag.CommandLine
+	fs.Usage = usage
+	registerContextFlags(fs, cfg)
+	registerLLMFlags(fs, cfg)
+	registerWriteFlags(fs, cfg)
+	registerManifestCacheFlags(fs, cfg)
+	configFile := registerSettingsFlags(fs)
+	reportFile := fs.String("report-file", "", "Write a JSON run report to this file")
+	reportMarkdown := fs.String("report-markdown-file", "", "Write a Markdown run report to this file")
 	flag.Parse()
 
 	// Validate required flags
-	if *issueTitleFile == "" || *issueBodyFile == "" {
-		log.Fatal("ERROR: --issue-title-file and --issue-body-file are required")
+	if err := cfg.requireIssue(); err != nil {
+		log.Fatalf("ERROR: %v", err)
 	}
 
-	// Parse exclude lists (nil = use defaults, empty slice = exclude nothing)
-	excludeDirsList := parseCommaSeparatedList(*excludeDirs)
-	excludeFilesList := parseCommaSeparatedList(*excludeFiles)
+	// Merge defaults, config file, environment and flags
+	settings, err := loadSettings(*configFile, fs)
+	if err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+	cfg.settings = settings
 
 	// Set up global timeout
 	appCtx, cancel := context.WithTimeout(context.Background(), appTimeout)
 	defer cancel()
 
-	if err := run(appCtx, config{
-		issueTitleFile: *issueTitleFile,
-		issueBodyFile:  *issueBodyFile,
-		prTitleFile:    *prTitleFile,
-		prBodyFile:     *prBodyFile,
-		diffFile:       *diffFile,
-		glossaryFile:   *glossaryFile,
-		docsDir:        *docsDir,
-		excludeDirs:    excludeDirsList,
-		excludeFiles:   excludeFilesList,
-	}); err != nil {
-		log.Fatalf("ERROR: %v", err)
+	rep := report.New()
+	runErr := run(appCtx, *cfg, rep)
+
+	// Write reports even when the run failed so the failure reason is recorded
+	rep.Finish(runErr)
+	if *reportFile != "" {
+		if err := rep.WriteJSON(*reportFile); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	if *reportMarkdown != "" {
+		if err := rep.WriteMarkdown(*reportMarkdown); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	if runErr != nil {
+		log.Fatalf("ERROR: %v", runErr)
 	}
 }
 
 type config struct {
+	issueNumber    int   // fetch context from GitHub instead of files when set
+	prNumbers      []int // PRs to fetch (empty = none, unless discoverPRs)
+	discoverPRs    bool  // without prNumbers, fetch the merged PRs linked to the issue
 	issueTitleFile string
 	issueBodyFile  string
 	prTitleFile    string
 	prBodyFile     string
 	diffFile       string
 	glossaryFile   string
-	docsDir        string
-	excludeDirs    []string // nil = use defaults, empty slice = exclude nothing
-	excludeFiles   []string // nil = use defaults, empty slice = exclude nothing
+	manifestFile   string // saved manifest to use instead of scanning docs (subcommands only)
+	manifestCache  string // manifest cache file (empty = no cache)
+	rebuild        bool   // ignore the existing manifest cache and rewrite it
+	dryRun         bool   // print diffs instead of writing files
+	patchFile      string // dry-run output file (empty = stdout)
+	cassetteMode   cassette.Mode
+	cassetteDir    string
+	settings       *appconfig.Config // merged project configuration
 }
 
-func run(ctx context.Context, cfg config) error {
-	// 1. Load Issue context
-	issueCtx, err := appctx.LoadIssue(cfg.issueTitleFile, cfg.issueBodyFile)
-	if err != nil {
-		return fmt.Errorf("failed to load issue context: %w", err)
+// requireIssue checks that an issue was given, either as files or as a number.
+func (c config) requireIssue() error {
+	if c.issueNumber == 0 && (c.issueTitleFile == "" || c.issueBodyFile == "") {
+		return errors.New("--issue-number or --issue-title-file and --issue-body-file are required")
 	}
-	log.Printf("Issue: %q", issueCtx.Title)
+	return nil
+}
 
-	// 2. Load PR context
-	prCtx, err := appctx.LoadPR(cfg.prTitleFile, cfg.prBodyFile, cfg.diffFile)
-	if err != nil {
-		return fmt.Errorf("failed to load PR context: %w", err)
+// newSource returns the GitHub source when an issue number was given,
+// otherwise a source reading the context files.
+func newSource(cfg config) (appctx.Source, error) {
+	if cfg.issueNumber == 0 {
+		return &appctx.FileSource{
+			IssueTitleFile: cfg.issueTitleFile,
+			IssueBodyFile:  cfg.issueBodyFile,
+			PRTitleFile:    cfg.prTitleFile,
+			PRBodyFile:     cfg.prBodyFile,
+			DiffFile:       cfg.diffFile,
+		}, nil
 	}
-	log.Printf("PR: %q", prCtx.Title)
+	return appctx.NewGitHubSource(cfg.settings.Source.Repo,
+		appctx.WithBaseURL(cfg.settings.Source.APIURL), appctx.WithPRDiscovery(cfg.discoverPRs))
+}
 
-	// 3. Load glossary (optional - continue without it if loading fails)
-	glossaryEntries, err := glossary.Load(cfg.glossaryFile)
-	if err != nil {
-		log.Printf("Warning: Failed to load glossary: %v (continuing without glossary)", err)
-		glossaryEntries = nil
-	} else {
-		log.Printf("Loaded %d glossary entries", len(glossaryEntries))
-	}
+// docWriter writes generated content for a manifest path.
+// Implemented by file.Writer and file.DryRunWriter.
+type docWriter interface {
+	Write(relativePath, content string) error
+}
+
+func run(ctx context.Context, cfg config, rep *report.Report) error {
+	rep.DryRun = cfg.dryRun
+
+	inputGuard := inputGuardrails(cfg.settings)
+	rep.Tokenizer = inputGuard.Tokenizer.String()
 
-	// 3.5. Load style guide (optional - continue with defaults if loading fails)
-	styleGuideDir := filepath.Join(cfg.docsDir, "contribution-guide", "documentation-style")
-	styleGuideData, err := styleguide.Load(styleGuideDir)
+	// 1-3. Load issue/PR context (secrets redacted), glossary and style guide
+	contextPhase := rep.StartPhase("load_context")
+	in, err := loadInputs(ctx, cfg, inputGuard)
 	if err != nil {
-		log.Printf("Warning: Failed to load style guide: %v (using defaults)", err)
-	} else {
-		log.Printf("Loaded %d style guide rules", styleGuideData.RuleCount())
+		contextPhase.End(report.StatusFailed, err.Error())
+		if errors.Is(err, guardrails.ErrSecretsDetected) {
+			rep.Reason = report.ReasonSecretsDetected // Fail closed: nothing is sent to the LLM
+		}
+		return err
 	}
-	formattedStyleGuide := styleGuideData.Format()
-
-	// 4. Input guardrails validation
-	inputGuard := guardrails.NewInputGuardrails()
-	if err := inputGuard.ValidateContext(issueCtx, prCtx); err != nil {
-		log.Printf("Input guardrails triggered (skipping): %v", err)
+	issueCtx, prCtx := in.issue, in.pr
+	rep.IssueTitle = issueCtx.Title
+	rep.PRTitle = prCtx.Title
+	rep.Redactions = in.redactions
+	contextPhase.End(report.StatusCompleted, fmt.Sprintf("%d glossary entries, %d style guide rules",
+		len(in.glossary), in.styleGuideRules))
+
+	// 4. Input guardrails validation (summarizes large diffs)
+	guardPhase := rep.StartPhase("input_guardrails")
+	reason, err := checkInputs(in, inputGuard, cfg.settings.Limits.MaxDiffSizeBytes)
+	rep.FilteredPaths = in.filteredPaths
+	if reason != "" {
+		guardPhase.End(report.StatusSkipped, err.Error())
+		rep.Status, rep.Reason = report.StatusSkipped, reason
 		return nil // Skip without error - this is expected behavior
 	}
-
-	// 4.5. Summarize large diffs and validate structure
-	if prCtx.Diff != "" {
-		// Summarize if diff is too large
-		originalSize := len(prCtx.Diff)
-		prCtx.Diff = guardrails.SummarizeLargeDiff(prCtx.Diff, guardrails.MaxDiffSizeBytes)
-		if len(prCtx.Diff) < originalSize {
-			log.Printf("Large diff summarized: %d → %d bytes", originalSize, len(prCtx.Diff))
-		}
-
-		// Validate diff structure (file count, line count)
-		parsedDiff := guardrails.ParseDiff(prCtx.Diff)
-		if err := inputGuard.ValidateDiff(parsedDiff); err != nil {
-			log.Printf("Diff guardrails triggered (skipping): %v", err)
-			return nil
-		}
-		log.Printf("Diff validated: %d files, %d bytes", len(parsedDiff.Files), parsedDiff.TotalSize)
+	guardDetail := ""
+	if len(in.filteredPaths) > 0 {
+		guardDetail = fmt.Sprintf("%d changed files filtered", len(in.filteredPaths))
 	}
+	guardPhase.End(report.StatusCompleted, guardDetail)
 
 	// 5. Generate docs manifest (nil = use defaults for exclusions)
-	manifest, err := docs.GenerateManifest(cfg.docsDir, cfg.excludeDirs, cfg.excludeFiles)
+	manifestPhase := rep.StartPhase("manifest")
+	docsDir := cfg.settings.Docs.Dir
+	manifest, _, err := loadManifest(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to generate docs manifest: %w", err)
+		manifestPhase.End(report.StatusFailed, err.Error())
+		return err
 	}
-	log.Printf("Found %d documentation files (excluded dirs: %v, excluded files: %v)",
-		len(manifest.Files),
-		withDefault(cfg.excludeDirs, docs.DefaultExcludeDirs),
-		withDefault(cfg.excludeFiles, docs.DefaultExcludeFiles))
+	manifestPhase.End(report.StatusCompleted, fmt.Sprintf("%d documentation files", len(manifest.Files)))
 
 	// 6. Log context summary (for debugging)
-	logContextSummary(issueCtx, prCtx, glossaryEntries, manifest)
+	logContextSummary(issueCtx, prCtx, in.glossary, manifest)
 
 	// Check for context cancellation
 	select {
@@ -156,44 +197,65 @@ func run(ctx context.Context, cfg config) error {
 	default:
 	}
 
-	// 7. Initialize OpenAI client
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		return fmt.Errorf("OPENAI_API_KEY environment variable is not set")
+	// 7. Initialize LLM provider
+	providerPhase := rep.StartPhase("provider")
+	provider, err := newProvider(cfg)
+	if err != nil {
+		providerPhase.End(report.StatusFailed, err.Error())
+		rep.Reason = report.ReasonProviderConfig // Unknown provider, missing API key or cassette setup
+		return err
 	}
-	client := openai.NewClient(apiKey)
+	providerPhase.End(report.StatusCompleted, "")
+	rep.Provider = provider.Name()
+	rep.Model = provider.Model()
+	defer func() {
+		usage := provider.Usage()
+		rep.Usage = &report.Usage{
+			Requests:         usage.Requests,
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+		}
+	}()
 
 	// 8. Phase 1: AI identifies which docs to update
 	log.Println("Phase 1: Identifying documents to update...")
-
-	// Create diff summary for Phase 1 (efficient format, not full diff)
-	diffSummary := guardrails.SummarizeDiff(prCtx.Diff)
-
-	// Token limit check for Phase 1 (includes glossary, manifest, diff summary)
-	phase1TokenEstimate := estimatePhase1Tokens(issueCtx, prCtx, glossaryEntries, manifest, diffSummary)
-	if phase1TokenEstimate > guardrails.MaxInputTokens {
-		log.Printf("Phase 1 token limit exceeded: ~%d tokens (max %d)", phase1TokenEstimate, guardrails.MaxInputTokens)
-		return fmt.Errorf("phase 1 context too large: ~%d tokens", phase1TokenEstimate)
-	}
-	log.Printf("Phase 1 token estimate: ~%d tokens", phase1TokenEstimate)
-
-	identification, err := client.IdentifyDocsToUpdate(ctx, openai.IdentifyRequest{
-		IssueTitle:   issueCtx.Title,
-		IssueBody:    issueCtx.Body,
-		PRTitle:      prCtx.Title,
-		PRBody:       prCtx.Body,
-		DiffSummary:  diffSummary,
-		Glossary:     toOpenAIGlossary(glossaryEntries),
-		DocsManifest: toOpenAIDocsManifest(manifest),
-	})
+	identifyPhase := rep.StartPhase("identify")
+	candidates := selectCandidates(in, manifest, cfg.settings.Docs.Dir, cfg.settings.Retrieval.TopN)
+	identification, phase1Tokens, err := identify(ctx, provider, in, candidates, inputGuard, cfg.settings.Limits.MaxFilesToUpdate)
+	rep.SetTokens("phase1", phase1Tokens)
 	if err != nil {
-		return fmt.Errorf("failed to identify docs: %w", err)
+		if errors.Is(err, guardrails.ErrTokenLimitExceeded) {
+			identifyPhase.End(report.StatusFailed, "token limit exceeded")
+			rep.Reason = report.ReasonTokenLimitExceeded
+			return err
+		}
+		identifyPhase.End(report.StatusFailed, err.Error())
+		rep.Reason = report.ReasonLLMError
+		return err
+	}
+	// Hub pages, off-platform docs and missing target headings are rejected, whatever the model selected
+	for _, r := range checkTargets(manifest, in.route, identification) {
+		rep.AddFile(r.file.Path, r.file.UpdateType, r.file.BriefDescription, r.file.TargetLocation).
+			Skip(r.reason, r.err)
+	}
+
+	rep.Identify = &report.IdentifyResult{
+		NeedsUpdate:  identification.NeedsUpdate,
+		Reason:       identification.Reason,
+		ManifestDocs: len(manifest.Files),
+		PromptDocs:   len(candidates.Files),
+	}
+	for _, t := range in.route {
+		rep.Identify.Platforms = append(rep.Identify.Platforms, t.String())
 	}
 
 	if !identification.NeedsUpdate {
 		log.Printf("AI determined no docs need updating: %s", identification.Reason)
+		identifyPhase.End(report.StatusNoUpdate, identification.Reason)
+		rep.Status = report.StatusNoUpdate
 		return nil
 	}
+	identifyPhase.End(report.StatusCompleted, fmt.Sprintf("%d files identified", len(identification.FilesToUpdate)))
 
 	log.Printf("AI identified %d files to update", len(identification.FilesToUpdate))
 	for _, f := range identification.FilesToUpdate {
@@ -202,93 +264,275 @@ func run(ctx context.Context, cfg config) error {
 
 	// 9. Phase 2: Generate updates for each identified file
 	log.Println("Phase 2: Generating document updates...")
-	outputGuard := guardrails.NewOutputGuardrails()
+	generatePhase := rep.StartPhase("generate")
+	outputGuard := cfg.settings.OutputGuardrails()
 	var successCount int
 
 	// Create Writer with manifest paths for validation
-	manifestPaths := getManifestPaths(manifest)
-	writer := file.NewWriter(cfg.docsDir, manifestPaths)
+	writer, closeWriter, err := newDocWriter(cfg, manifest)
+	if err != nil {
+		generatePhase.End(report.StatusFailed, err.Error())
+		return err
+	}
+	defer closeWriter()
+
+	gen := &generator{
+		provider:    provider,
+		inputs:      in,
+		manifest:    manifest,
+		docsDir:     docsDir,
+		inputGuard:  inputGuard,
+		outputGuard: outputGuard,
+	}
+	results := generateAll(ctx, identification.FilesToUpdate, cfg.settings.Generation.Concurrency, gen.generate)
+
+	// Write results one at a time, in the order Phase 1 returned them
+	for _, result := range results {
+		fileUpdate := result.update
+		fileResult := rep.AddFile(fileUpdate.Path, fileUpdate.UpdateType, fileUpdate.BriefDescription, fileUpdate.TargetLocation)
+		fileResult.Warnings = result.warnings
+		if result.tokens > 0 {
+			rep.SetTokens("phase2:"+fileUpdate.Path, result.tokens)
+		}
+		if result.skipped() {
+			fileResult.Skip(result.reason, result.err)
+			continue
+		}
 
-	for _, fileUpdate := range identification.FilesToUpdate {
-		log.Printf("Processing: %s (%s)", fileUpdate.Path, fileUpdate.UpdateType)
+		// Write file (validates path is in manifest)
+		if err := writer.Write(fileUpdate.Path, result.content); err != nil {
+			log.Printf("ERROR: Failed to write %s: %v (skipping)", fileUpdate.Path, err)
+			fileResult.Skip(reasonFromError(err), err)
+			continue
+		}
+		if cfg.dryRun {
+			log.Printf("Computed diff for: %q", fileUpdate.Path)
+			fileResult.Outcome = report.OutcomeDiffed
+		} else {
+			log.Printf("Successfully updated: %q", fileUpdate.Path)
+			fileResult.Outcome = report.OutcomeUpdated
+		}
 
-		// Build full path for reading (docsDir + relative path from manifest)
-		fullPath := filepath.Join(cfg.docsDir, fileUpdate.Path)
+		successCount++
+	}
 
-		// Look up content type from manifest
-		contentType := findContentType(manifest, fileUpdate.Path)
+	generatePhase.End(report.StatusCompleted, fmt.Sprintf("%d/%d files generated", successCount, len(identification.FilesToUpdate)))
+	if dw, ok := writer.(*file.DryRunWriter); ok {
+		log.Printf("Dry run completed: %d/%d files generated, %d with changes",
+			successCount, len(identification.FilesToUpdate), dw.ChangedCount())
+		return nil
+	}
+	log.Printf("Completed: %d/%d files updated", successCount, len(identification.FilesToUpdate))
+	return nil
+}
 
-		// Read current content
-		currentContent, err := docs.ReadFile(fullPath)
+// inputGuardrails returns the configured input guardrails, counting tokens
+// with the model's tokenizer (or estimating them for models without one).
+func inputGuardrails(settings *appconfig.Config) *guardrails.InputGuardrails {
+	g := settings.InputGuardrails()
+	tok, err := tokenizer.ForModel(settings.LLM.Model)
+	if err != nil {
+		log.Printf("Warning: Failed to load tokenizer for %s: %v (using heuristic token estimates)", settings.LLM.Model, err)
+	}
+	g.Tokenizer = tok
+	log.Printf("Token budget: %d input tokens (tokenizer: %s)", g.MaxInputTokens, tok)
+	return g
+}
+
+// loadManifest loads the saved manifest if one was given, otherwise scans the
+// docs directory, and returns it with its link graph. A scanned manifest has
+// the graph applied; a saved one already carries the link fields, so its graph
+// is only rebuilt from the saved links.
+func loadManifest(cfg config) (*docs.Manifest, *docs.LinkGraph, error) {
+	if cfg.manifestFile != "" {
+		manifest, err := docs.LoadManifest(cfg.manifestFile)
 		if err != nil {
-			log.Printf("ERROR: Failed to read %s: %v (skipping)", fileUpdate.Path, err)
-			continue
+			return nil, nil, err
 		}
+		log.Printf("Loaded %d documentation files from %s", len(manifest.Files), cfg.manifestFile)
+		return manifest, docs.BuildLinkGraph(manifest, cfg.settings.Docs.Dir, cfg.settings.Docs.IgnoreLinks), nil
+	}
 
-		// Validate document content size
-		if err := inputGuard.ValidateDocContent(currentContent); err != nil {
-			log.Printf("Document too large for %s (skipping): %v", fileUpdate.Path, err)
-			continue
-		}
+	rules, err := loadRules(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		// Token limit check
-		combinedContext := issueCtx.String() + prCtx.String()
-		if err := inputGuard.ValidateTokenLimit(combinedContext, currentContent); err != nil {
-			log.Printf("Token limit exceeded for %s (skipping): %v", fileUpdate.Path, err)
-			continue
+	cache, err := loadManifestCache(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	manifest, err := docs.GenerateManifest(cfg.settings.Docs.Dir, cfg.settings.Docs.ExcludeDirs, cfg.settings.Docs.ExcludeFiles,
+		docs.WithRules(rules), docs.WithCache(cache))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate docs manifest: %w", err)
+	}
+	if cfg.manifestCache != "" {
+		reused, parsed := cache.Stats()
+		log.Printf("Manifest cache: %d files reused, %d parsed", reused, parsed)
+		// The cache only saves work; a failure to write it does not fail the run
+		if err := cache.Save(cfg.manifestCache); err != nil {
+			log.Printf("Warning: %v (continuing without saving the cache)", err)
 		}
+	}
+	log.Printf("Found %d documentation files (excluded dirs: %v, excluded files: %v)",
+		len(manifest.Files),
+		withDefault(cfg.settings.Docs.ExcludeDirs, docs.DefaultExcludeDirs),
+		withDefault(cfg.settings.Docs.ExcludeFiles, docs.DefaultExcludeFiles))
 
-		// Generate update (Full file content)
-		rawResult, err := client.GenerateDocUpdate(ctx, openai.UpdateRequest{
-			IssueTitle:        issueCtx.Title,
-			IssueBody:         issueCtx.Body,
-			PRTitle:           prCtx.Title,
-			PRBody:            prCtx.Body,
-			CodeDiff:          prCtx.Diff,
-			Glossary:          toOpenAIGlossary(glossaryEntries),
-			DocPath:           fileUpdate.Path,
-			CurrentContent:    currentContent,
-			UpdateInstruction: fileUpdate.BriefDescription,
-			ContentType:       contentType,
-			StyleGuide:        formattedStyleGuide,
-			UpdateType:        fileUpdate.UpdateType,
-		})
+	// Attach the sidebar hierarchy (optional - continue without it if loading fails)
+	if sidebarsPath := cfg.settings.SidebarsPath(); sidebarsPath != "" {
+		sidebars, err := sidebar.Load(sidebarsPath)
 		if err != nil {
-			log.Printf("ERROR: OpenAI error for %s: %v (skipping)", fileUpdate.Path, err)
-			continue
+			log.Printf("Warning: Failed to load sidebars: %v (continuing without hierarchy)", err)
+		} else {
+			docs.ApplySidebars(manifest, sidebars)
+			log.Printf("Loaded sidebars: %d docs, %d hub pages", sidebars.Len(), countHubs(manifest))
 		}
+	}
 
-		// Output guardrails validation
-		if err := outputGuard.Validate(rawResult); err != nil {
-			log.Printf("Output guardrails triggered for %s: %v (skipping)", fileUpdate.Path, err)
-			continue
+	graph := docs.BuildLinkGraph(manifest, cfg.settings.Docs.Dir, cfg.settings.Docs.IgnoreLinks)
+	docs.ApplyLinkGraph(manifest, graph)
+	log.Printf("Link graph: %d docs link to other docs, %d broken links", len(graph.Outbound), len(graph.Broken))
+	return manifest, graph, nil
+}
+
+// loadManifestCache loads the manifest cache, or returns an empty cache when
+// caching is disabled or a rebuild is requested. An unreadable cache is
+// discarded with a warning rather than failing the run.
+func loadManifestCache(cfg config) (*docs.ManifestCache, error) {
+	if cfg.manifestCache == "" || cfg.rebuild {
+		return docs.NewManifestCache(), nil
+	}
+	cache, err := docs.LoadManifestCache(cfg.manifestCache)
+	if err != nil {
+		log.Printf("Warning: %v (rebuilding the manifest)", err)
+		return docs.NewManifestCache(), nil
+	}
+	return cache, nil
+}
+
+// loadRules loads the configured classification rules, or the built-in rules.
+func loadRules(cfg config) (*docs.Rules, error) {
+	rulesPath := cfg.settings.RulesPath()
+	if rulesPath == "" {
+		return docs.DefaultRules(), nil
+	}
+	rules, err := docs.LoadRules(rulesPath)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Loaded %d classification rules from %s", len(rules.Rules), rulesPath)
+	return rules, nil
+}
+
+// countHubs returns the number of hub pages in the manifest.
+func countHubs(m *docs.Manifest) int {
+	var n int
+	for _, f := range m.Files {
+		if f.IsHub {
+			n++
 		}
+	}
+	return n
+}
 
-		// Extract document content
-		content := guardrails.ExtractDocumentContent(rawResult)
-		if content == "" {
-			log.Printf("ERROR: Failed to extract content for %s (skipping)", fileUpdate.Path)
-			continue
+// newDocWriter creates the file writer, or a diff writer in dry-run mode.
+// The returned close function must be called once writing is finished.
+func newDocWriter(cfg config, manifest *docs.Manifest) (docWriter, func(), error) {
+	docsDir := cfg.settings.Docs.Dir
+	manifestPaths := getManifestPaths(manifest)
+	if !cfg.dryRun {
+		return file.NewWriter(docsDir, manifestPaths), func() {}, nil
+	}
+
+	out, closeOut := os.Stdout, func() {}
+	if cfg.patchFile != "" {
+		f, err := os.Create(cfg.patchFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create patch file: %w", err)
 		}
+		out, closeOut = f, func() { f.Close() }
+	}
+	log.Println("Dry run: docs will not be modified")
+	return file.NewDryRunWriter(docsDir, manifestPaths, out), closeOut, nil
+}
+
+// newProvider creates the LLM provider selected by the configuration,
+// wiring in the cassette transport when recording or replaying.
+// Replay mode does not require an API key.
+func newProvider(cfg config) (llm.Provider, error) {
+	var keyEnv string
+	switch cfg.settings.LLM.Provider {
+	case openai.ProviderName:
+		keyEnv = openai.EnvOpenAIAPIKey
+	case anthropic.ProviderName:
+		keyEnv = anthropic.EnvAnthropicAPIKey
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q (supported: %s, %s)",
+			cfg.settings.LLM.Provider, openai.ProviderName, anthropic.ProviderName)
+	}
 
-		// Apply post-processing transformations (placeholder→TODO, non-ASCII→ASCII)
-		content, postProcessWarnings := outputGuard.PostProcess(content)
-		for _, w := range postProcessWarnings {
-			log.Printf("Post-process warning for %s: %s", fileUpdate.Path, w)
+	apiKey := os.Getenv(keyEnv)
+	if apiKey == "" {
+		if cfg.cassetteMode != cassette.ModeReplay {
+			return nil, fmt.Errorf("%s environment variable is not set", keyEnv)
 		}
+		apiKey = "replay" // never sent over the network
+	}
 
-		// Write file (validates path is in manifest)
-		if err := writer.Write(fileUpdate.Path, content); err != nil {
-			log.Printf("ERROR: Failed to write %s: %v (skipping)", fileUpdate.Path, err)
-			continue
+	var transport http.RoundTripper
+	if cfg.cassetteMode != cassette.ModeOff {
+		t, err := cassette.NewTransport(cfg.cassetteMode, cfg.cassetteDir, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up cassette: %w", err)
 		}
-		log.Printf("Successfully updated: %q", fileUpdate.Path)
+		log.Printf("Cassette %s mode: %s", cfg.cassetteMode, cfg.cassetteDir)
+		transport = t
+	}
 
-		successCount++
+	model := cfg.settings.LLM.Model
+	var provider llm.Provider
+	if cfg.settings.LLM.Provider == anthropic.ProviderName {
+		provider = anthropic.NewClient(apiKey, anthropic.WithHTTPTransport(transport), anthropic.WithModel(model))
+	} else {
+		provider = openai.NewClient(apiKey, openai.WithHTTPTransport(transport), openai.WithModel(model))
 	}
+	log.Printf("Using %s provider (model: %s)", provider.Name(), provider.Model())
+	return provider, nil
+}
 
-	log.Printf("Completed: %d/%d files updated", successCount, len(identification.FilesToUpdate))
-	return nil
+// reasonFromError maps guardrail and writer errors to stable report reasons.
+func reasonFromError(err error) report.Reason {
+	switch {
+	case errors.Is(err, guardrails.ErrDocTooLarge):
+		return report.ReasonDocTooLarge
+	case errors.Is(err, guardrails.ErrTokenLimitExceeded):
+		return report.ReasonTokenLimitExceeded
+	case errors.Is(err, guardrails.ErrMissingDocumentTags):
+		return report.ReasonMissingDocumentTags
+	case errors.Is(err, guardrails.ErrOutputTooLarge):
+		return report.ReasonOutputTooLarge
+	case errors.Is(err, guardrails.ErrInvalidMarkdown):
+		return report.ReasonInvalidMarkdown
+	case errors.Is(err, guardrails.ErrEmptyContent):
+		return report.ReasonEmptyContent
+	case errors.Is(err, file.ErrPathNotInManifest):
+		return report.ReasonPathNotInManifest
+	case errors.Is(err, docs.ErrHubPage):
+		return report.ReasonHubPage
+	case errors.Is(err, docs.ErrTargetNotFound):
+		return report.ReasonTargetNotFound
+	case errors.Is(err, docs.ErrPlatformMismatch):
+		return report.ReasonPlatformMismatch
+	case errors.Is(err, docs.ErrStructureChanged):
+		return report.ReasonStructureChanged
+	case err != nil:
+		return report.ReasonWriteFailed
+	default:
+		return report.ReasonOther
+	}
 }
 
 func logContextSummary(
@@ -345,30 +589,34 @@ func toOpenAIDocsManifest(m *docs.Manifest) *openai.DocsManifest {
 			Category:    f.Category,
 			Audience:    f.Audience,
 			ContentType: string(f.ContentType),
+			Platform:    f.Platform,
+			SDKKind:     string(f.SDKKind),
+			Parent:      f.Parent,
+			Children:    f.Children,
+			IsHub:       f.IsHub,
+			Sections:    sectionHeadings(f.Outline),
+			LinksTo:     f.LinksTo,
+			Inbound:     f.InboundLinks,
 		}
 	}
 	return &openai.DocsManifest{Files: files}
 }
 
-// parseCommaSeparatedList splits a comma-separated string into a trimmed slice.
-// Returns nil for empty input (to use defaults), or a slice of trimmed values.
-func parseCommaSeparatedList(s string) []string {
-	if s == "" {
-		return nil
-	}
-	parts := strings.Split(s, ",")
-	for i := range parts {
-		parts[i] = strings.TrimSpace(parts[i])
+// sectionHeadings returns the ## and ### headings of an outline for the identify prompt.
+func sectionHeadings(outline []docs.Heading) []string {
+	var sections []string
+	for _, h := range outline {
+		if h.Level == 2 || h.Level == 3 {
+			sections = append(sections, h.String())
+		}
 	}
-	return parts
+	return sections
 }
 
 // findContentType looks up the content type for a file path from the manifest.
 func findContentType(m *docs.Manifest, path string) string {
-	for _, f := range m.Files {
-		if f.Path == path {
-			return string(f.ContentType)
-		}
+	if f := m.FindFile(path); f != nil {
+		return string(f.ContentType)
 	}
 	return "user-guide"
 }
@@ -396,6 +644,7 @@ func getManifestPaths(m *docs.Manifest) []string {
 // estimatePhase1Tokens estimates token count for Phase 1 prompt.
 // Includes: issue, PR, glossary, manifest, diff summary, and prompt template overhead.
 func estimatePhase1Tokens(
+	inputGuard *guardrails.InputGuardrails,
 	issue *appctx.IssueContext,
 	pr *appctx.PRContext,
 	glossaryEntries []glossary.Entry,
@@ -407,25 +656,30 @@ func estimatePhase1Tokens(
 
 	// Issue context
 	if issue != nil {
-		tokens += guardrails.EstimateTokens(issue.Title)
-		tokens += guardrails.EstimateTokens(issue.Body)
+		tokens += inputGuard.CountTokens(issue.Title)
+		tokens += inputGuard.CountTokens(issue.Body)
 	}
 
 	// PR context
 	if pr != nil {
-		tokens += guardrails.EstimateTokens(pr.Title)
-		tokens += guardrails.EstimateTokens(pr.Body)
+		tokens += inputGuard.CountTokens(pr.Title)
+		tokens += inputGuard.CountTokens(pr.Body)
 	}
 
 	// Diff summary
-	tokens += guardrails.EstimateTokens(diffSummary)
+	tokens += inputGuard.CountTokens(diffSummary)
 
 	// Glossary (estimate ~20 tokens per entry)
 	tokens += len(glossaryEntries) * 20
 
-	// Manifest (estimate ~30 tokens per file entry)
+	// Manifest (estimate ~30 tokens per file entry, plus its summary, sections and links)
 	if manifest != nil {
 		tokens += len(manifest.Files) * 30
+		for _, f := range manifest.Files {
+			tokens += inputGuard.CountTokens(f.Description)
+			tokens += inputGuard.CountTokens(strings.Join(sectionHeadings(f.Outline), " | "))
+			tokens += inputGuard.CountTokens(strings.Join(f.LinksTo, ", "))
+		}
 	}
 
 	return tokens
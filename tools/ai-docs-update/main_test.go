@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/cassette"
+	appconfig "github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/config"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/llmtest"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/openai"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/report"
+)
+
+// recordCassettes re-records the cassettes under testdata against the fake
+// LLM server; run it after changing the prompts:
+//
+//	go test -run TestRunReplaysCassette -record-cassettes
+var recordCassettes = flag.Bool("record-cassettes", false, "Re-record testdata/cassettes against the fake LLM server")
+
+const (
+	segmentsPath = "feature-flags/segments.mdx"
+	overviewPath = "feature-flags/overview.mdx"
+)
+
+const segmentsDoc = `---
+title: Segments
+description: Group users to target them together.
+---
+
+# Segments
+
+Segments group users so flags can target them together.
+
+## Rules
+
+Add a rule to include users by attribute.
+`
+
+const overviewDoc = `---
+title: Overview
+---
+
+# Overview
+
+Feature flags let you change behavior without deploying.
+`
+
+// updatedSegmentsDoc is segmentsDoc with a sentence added under "## Rules".
+var updatedSegmentsDoc = segmentsDoc + "\nRules can also match users by their country.\n"
+
+// identifySegments is a Phase 1 response selecting the segments doc.
+var identifySegments = openai.IdentifyResponse{
+	NeedsUpdate: true,
+	Reason:      "Segment rules gained country matching",
+	FilesToUpdate: []openai.FileToUpdate{{
+		Path:             segmentsPath,
+		UpdateType:       openai.UpdateTypeAddInline,
+		BriefDescription: "Mention country matching",
+		TargetLocation:   "## Rules",
+	}},
+}
+
+// newTestRun writes a docs tree with a hub page and a regular doc, plus the
+// issue context files, and points the OpenAI client at srv. It returns the
+// config for run and the docs directory.
+func newTestRun(t *testing.T, srv *llmtest.Server) (config, string) {
+	t.Helper()
+	root := t.TempDir()
+	docsDir := filepath.Join(root, "docs")
+	files := map[string]string{
+		filepath.Join(docsDir, segmentsPath): segmentsDoc,
+		filepath.Join(docsDir, overviewPath): overviewDoc,
+		filepath.Join(root, "sidebars.json"): `{"docs": [{"type": "category", "label": "Feature flags", "items": ["feature-flags/overview", "feature-flags/segments"]}]}`,
+		filepath.Join(root, "title.txt"):     "Match segment rules by country",
+		filepath.Join(root, "body.txt"):      "Segment rules can now match users by country.",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Setenv(openai.EnvOpenAIAPIKey, "test")
+	t.Setenv(openai.EnvOpenAIAPIBase, srv.APIBase())
+
+	settings := appconfig.Default()
+	settings.Docs.Dir = docsDir
+	settings.Docs.SidebarsFile = "../sidebars.json"
+	settings.LLM.Model = openai.DefaultModel
+	return config{
+		issueTitleFile: filepath.Join(root, "title.txt"),
+		issueBodyFile:  filepath.Join(root, "body.txt"),
+		settings:       settings,
+	}, docsDir
+}
+
+// readDoc returns the content of a doc under docsDir.
+func readDoc(t *testing.T, docsDir, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(docsDir, path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// checkFile checks the report entry of the only file in the run.
+func checkFile(t *testing.T, rep *report.Report, outcome report.Outcome, reason report.Reason) {
+	t.Helper()
+	if len(rep.Files) != 1 {
+		t.Fatalf("report files = %d, want 1", len(rep.Files))
+	}
+	f := rep.Files[0]
+	if f.Path != segmentsPath || f.Outcome != outcome || f.Reason != reason {
+		t.Errorf("file = %s %s (%s), want %s %s (%s)", f.Path, f.Outcome, f.Reason, segmentsPath, outcome, reason)
+	}
+}
+
+func TestRunRetriesRateLimit(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+	srv.Handle(llmtest.IdentifyPhase(), llmtest.Status(http.StatusTooManyRequests), llmtest.Identify(identifySegments))
+	srv.Handle(llmtest.DocPath(segmentsPath), llmtest.Status(http.StatusTooManyRequests), llmtest.UpdatedDocument(updatedSegmentsDoc))
+	cfg, docsDir := newTestRun(t, srv)
+
+	rep := report.New()
+	if err := run(context.Background(), cfg, rep); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	checkFile(t, rep, report.OutcomeUpdated, "")
+	if got := readDoc(t, docsDir, segmentsPath); got != updatedSegmentsDoc {
+		t.Errorf("segments doc =\n%s\nwant\n%s", got, updatedSegmentsDoc)
+	}
+	if got := srv.RequestCount(llmtest.IdentifyPhase()); got != 2 {
+		t.Errorf("identify requests = %d, want 2 (429, then success)", got)
+	}
+	if got := srv.RequestCount(llmtest.GeneratePhase()); got != 2 {
+		t.Errorf("generate requests = %d, want 2 (429, then success)", got)
+	}
+	if rep.Usage == nil || rep.Usage.Requests != 2 {
+		t.Errorf("usage = %+v, want 2 successful requests", rep.Usage)
+	}
+}
+
+func TestRunGenerationFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   llmtest.Reply
+		timeout time.Duration
+		reason  report.Reason
+	}{
+		{
+			name:    "timeout",
+			reply:   llmtest.Timeout(time.Minute),
+			timeout: 2 * time.Second,
+			reason:  report.ReasonLLMError,
+		},
+		{
+			name:   "truncated output",
+			reply:  llmtest.Truncated(updatedSegmentsDoc, 120),
+			reason: report.ReasonMissingDocumentTags,
+		},
+		{
+			name:   "missing tags",
+			reply:  llmtest.MissingTags(updatedSegmentsDoc),
+			reason: report.ReasonMissingDocumentTags,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := llmtest.NewServer()
+			defer srv.Close()
+			srv.Handle(llmtest.IdentifyPhase(), llmtest.Identify(identifySegments))
+			srv.Handle(llmtest.DocPath(segmentsPath), tt.reply)
+			cfg, docsDir := newTestRun(t, srv)
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			rep := report.New()
+			// A failed generation skips the file; the run itself succeeds
+			if err := run(ctx, cfg, rep); err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+
+			checkFile(t, rep, report.OutcomeSkipped, tt.reason)
+			if got := readDoc(t, docsDir, segmentsPath); got != segmentsDoc {
+				t.Errorf("segments doc was modified:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestRunRejectsTargets(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+	srv.Handle(llmtest.IdentifyPhase(), llmtest.Identify(openai.IdentifyResponse{
+		NeedsUpdate: true,
+		Reason:      "Segment rules gained country matching",
+		FilesToUpdate: []openai.FileToUpdate{
+			{Path: overviewPath, UpdateType: openai.UpdateTypeAddSection, BriefDescription: "Add a country section"},
+			{Path: segmentsPath, UpdateType: openai.UpdateTypeModifySection, BriefDescription: "Mention country matching", TargetLocation: "## Country targeting"},
+		},
+	}))
+	cfg, docsDir := newTestRun(t, srv)
+
+	rep := report.New()
+	if err := run(context.Background(), cfg, rep); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	if rep.Status != report.StatusNoUpdate {
+		t.Errorf("status = %q, want %q once every file is rejected", rep.Status, report.StatusNoUpdate)
+	}
+	want := map[string]report.Reason{
+		overviewPath: report.ReasonHubPage,
+		segmentsPath: report.ReasonTargetNotFound,
+	}
+	if len(rep.Files) != len(want) {
+		t.Fatalf("report files = %d, want %d", len(rep.Files), len(want))
+	}
+	for _, f := range rep.Files {
+		if f.Outcome != report.OutcomeSkipped || f.Reason != want[f.Path] {
+			t.Errorf("%s: %s (%s), want skipped (%s)", f.Path, f.Outcome, f.Reason, want[f.Path])
+		}
+	}
+	if got := srv.RequestCount(llmtest.GeneratePhase()); got != 0 {
+		t.Errorf("generate requests = %d, want 0", got)
+	}
+	if got := readDoc(t, docsDir, overviewPath); got != overviewDoc {
+		t.Errorf("overview doc was modified:\n%s", got)
+	}
+}
+
+func TestRunProviderConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		apiKey   string
+	}{
+		{name: "unknown provider", provider: "gemini", apiKey: "test"},
+		{name: "missing API key", provider: openai.ProviderName, apiKey: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := llmtest.NewServer()
+			defer srv.Close()
+			cfg, _ := newTestRun(t, srv)
+			cfg.settings.LLM.Provider = tt.provider
+			t.Setenv(openai.EnvOpenAIAPIKey, tt.apiKey)
+
+			rep := report.New()
+			err := run(context.Background(), cfg, rep)
+			if err == nil {
+				t.Fatal("run() error = nil, want a provider error")
+			}
+			rep.Finish(err)
+
+			if rep.Status != report.StatusFailed || rep.Reason != report.ReasonProviderConfig {
+				t.Errorf("report = %s (%s), want %s (%s)", rep.Status, rep.Reason, report.StatusFailed, report.ReasonProviderConfig)
+			}
+			last := rep.Phases[len(rep.Phases)-1]
+			if last.Name != "provider" || last.Status != report.StatusFailed || last.Detail != err.Error() {
+				t.Errorf("last phase = %+v, want the failed provider phase", last)
+			}
+			if n := len(srv.Requests()); n != 0 {
+				t.Errorf("LLM requests = %d, want 0", n)
+			}
+		})
+	}
+}
+
+func TestRunDryRun(t *testing.T) {
+	srv := llmtest.NewServer()
+	defer srv.Close()
+	srv.Handle(llmtest.IdentifyPhase(), llmtest.Identify(identifySegments))
+	srv.Handle(llmtest.DocPath(segmentsPath), llmtest.UpdatedDocument(updatedSegmentsDoc))
+	cfg, docsDir := newTestRun(t, srv)
+	cfg.dryRun = true
+	cfg.patchFile = filepath.Join(t.TempDir(), "update.patch")
+
+	rep := report.New()
+	if err := run(context.Background(), cfg, rep); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	if !rep.DryRun {
+		t.Error("report DryRun = false, want true")
+	}
+	checkFile(t, rep, report.OutcomeDiffed, "")
+	if got := readDoc(t, docsDir, segmentsPath); got != segmentsDoc {
+		t.Errorf("segments doc was modified in a dry run:\n%s", got)
+	}
+	patch, err := os.ReadFile(cfg.patchFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"+++ b/" + segmentsPath, "+Rules can also match users by their country."} {
+		if !strings.Contains(string(patch), want) {
+			t.Errorf("patch does not contain %q:\n%s", want, patch)
+		}
+	}
+}
+
+func TestRunReplaysCassette(t *testing.T) {
+	cassetteDir := filepath.Join("testdata", "cassettes", "segments-country")
+	if *recordCassettes {
+		srv := llmtest.NewServer()
+		defer srv.Close()
+		srv.Handle(llmtest.IdentifyPhase(), llmtest.Identify(identifySegments))
+		srv.Handle(llmtest.DocPath(segmentsPath), llmtest.UpdatedDocument(updatedSegmentsDoc))
+		cfg, _ := newTestRun(t, srv)
+		cfg.cassetteMode, cfg.cassetteDir = cassette.ModeRecord, cassetteDir
+		if err := os.RemoveAll(cassetteDir); err != nil {
+			t.Fatal(err)
+		}
+		if err := run(context.Background(), cfg, report.New()); err != nil {
+			t.Fatalf("recording: run() error = %v", err)
+		}
+	}
+
+	// A server without rules: any request reaching the network fails the run
+	srv := llmtest.NewServer()
+	defer srv.Close()
+	cfg, docsDir := newTestRun(t, srv)
+	t.Setenv(openai.EnvOpenAIAPIKey, "") // replay needs no API key
+	cfg.cassetteMode, cfg.cassetteDir = cassette.ModeReplay, cassetteDir
+
+	rep := report.New()
+	if err := run(context.Background(), cfg, rep); err != nil {
+		t.Fatalf("run() error = %v (re-record with -record-cassettes if the prompts changed)", err)
+	}
+
+	if got := len(srv.Requests()); got != 0 {
+		t.Errorf("requests sent to the network = %d, want 0", got)
+	}
+	if rep.Identify == nil || rep.Identify.Reason != identifySegments.Reason {
+		t.Errorf("identify = %+v, want the recorded Phase 1 response", rep.Identify)
+	}
+	checkFile(t, rep, report.OutcomeUpdated, "")
+	if got := readDoc(t, docsDir, segmentsPath); got != updatedSegmentsDoc {
+		t.Errorf("segments doc =\n%s\nwant\n%s", got, updatedSegmentsDoc)
+	}
+	if rep.Usage == nil || rep.Usage.Requests != 2 {
+		t.Errorf("usage = %+v, want 2 replayed requests", rep.Usage)
+	}
+}
@@ -12,6 +12,8 @@ import (
 	sdkopenai "github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/option"
 	"github.com/openai/openai-go/v3/shared"
+
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/llm"
 )
 
 // Default configuration values.
@@ -23,6 +25,9 @@ const (
 	RequestTimeout    = 2 * time.Minute
 )
 
+// ProviderName is the llm.Provider name of this client.
+const ProviderName = "openai"
+
 // Environment variable names.
 const (
 	EnvOpenAIAPIKey  = "OPENAI_API_KEY"
@@ -31,20 +36,23 @@ const (
 )
 
 // Client is an OpenAI API client using the official SDK.
+// It implements llm.Provider.
 type Client struct {
+	llm.UsageTracker
+
 	sdkClient   sdkopenai.Client
 	model       string
 	apiBase     string
 	temperature float64
 	maxTokens   int
 	maxRetries  int
+	transport   http.RoundTripper
 }
 
 // ChatMessage represents a message in the chat completion request.
-type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
+type ChatMessage = llm.ChatMessage
+
+var _ llm.Provider = (*Client)(nil)
 
 // ClientOption is a function that configures a Client.
 type ClientOption func(*Client)
@@ -84,6 +92,14 @@ func WithMaxRetries(retries int) ClientOption {
 	}
 }
 
+// WithHTTPTransport sets the HTTP transport used for API calls.
+// This allows recording or replaying calls (see the cassette package).
+func WithHTTPTransport(transport http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.transport = transport
+	}
+}
+
 // NewClient creates a new OpenAI API client using the official SDK.
 // It reads configuration from environment variables with sensible defaults.
 func NewClient(apiKey string, opts ...ClientOption) *Client {
@@ -117,7 +133,7 @@ func NewClient(apiKey string, opts ...ClientOption) *Client {
 		option.WithAPIKey(apiKey),
 		option.WithBaseURL(c.apiBase),
 		option.WithMaxRetries(c.maxRetries),
-		option.WithHTTPClient(&http.Client{Timeout: RequestTimeout}),
+		option.WithHTTPClient(&http.Client{Timeout: RequestTimeout, Transport: c.transport}),
 	)
 
 	return c
@@ -160,6 +176,8 @@ func (c *Client) CreateChatCompletion(ctx context.Context, messages []ChatMessag
 		return "", fmt.Errorf("openai api error: %w", err)
 	}
 
+	c.Record(int(completion.Usage.PromptTokens), int(completion.Usage.CompletionTokens))
+
 	if len(completion.Choices) == 0 {
 		return "", errors.New("no choices in response")
 	}
@@ -167,16 +185,41 @@ func (c *Client) CreateChatCompletion(ctx context.Context, messages []ChatMessag
 	return completion.Choices[0].Message.Content, nil
 }
 
+// ChatCompletion implements llm.Provider.
+func (c *Client) ChatCompletion(ctx context.Context, req llm.Request) (string, error) {
+	var opts []RequestOption
+	if req.JSONResponse {
+		opts = append(opts, WithJSONResponse())
+	}
+	return c.CreateChatCompletion(ctx, req.Messages, opts...)
+}
+
+// SupportsJSONMode implements llm.Provider.
+// The json_object response format is enforced by the Chat Completions API.
+func (c *Client) SupportsJSONMode() bool {
+	return true
+}
+
+// Name implements llm.Provider.
+func (c *Client) Name() string {
+	return ProviderName
+}
+
+// Model implements llm.Provider.
+func (c *Client) Model() string {
+	return c.model
+}
+
 // toSDKMessages converts ChatMessage slice to SDK message union types.
 func toSDKMessages(messages []ChatMessage) []sdkopenai.ChatCompletionMessageParamUnion {
 	result := make([]sdkopenai.ChatCompletionMessageParamUnion, len(messages))
 	for i, m := range messages {
 		switch m.Role {
-		case "system":
+		case llm.RoleSystem:
 			result[i] = sdkopenai.SystemMessage(m.Content)
-		case "user":
+		case llm.RoleUser:
 			result[i] = sdkopenai.UserMessage(m.Content)
-		case "assistant":
+		case llm.RoleAssistant:
 			result[i] = sdkopenai.AssistantMessage(m.Content)
 		default:
 			log.Printf("WARNING: unknown message role %q, treating as user message", m.Role)
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"strings"
 	"text/template"
+
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/llm"
 )
 
 // DocUpdatePrompt is the prompt template for Phase 2: Update Generation.
@@ -27,8 +29,11 @@ type UpdateRequest struct {
 	CurrentContent    string
 	UpdateInstruction string
 	ContentType       string // user-guide, admin-config, developer-reference
+	DocFormat         string // md or mdx (from the file extension)
 	StyleGuide        string // Formatted style guide rules
 	UpdateType        string // add_inline, modify_section, add_section, add_example
+	Structure         string // Imports, tab groups and admonitions to preserve (see docs.Structure.Describe)
+	TabsImported      bool   // Tabs and TabItem are imported, so new tab groups need no import
 }
 
 // updateTemplateData is the data structure for the update prompt template.
@@ -43,13 +48,16 @@ type updateTemplateData struct {
 	CurrentContent      string
 	UpdateInstruction   string
 	ContentType         string
+	DocFormat           string
 	StyleGuide          string
 	UpdateType          string
+	Structure           string
+	TabsImported        bool
 }
 
 // GenerateDocUpdate executes Phase 2: Update Generation.
 // It generates the updated documentation content based on the context provided.
-func (c *Client) GenerateDocUpdate(ctx context.Context, req UpdateRequest) (string, error) {
+func GenerateDocUpdate(ctx context.Context, provider llm.Provider, req UpdateRequest) (string, error) {
 	// Build prompt from template
 	prompt, err := buildUpdatePrompt(req)
 	if err != nil {
@@ -59,19 +67,18 @@ func (c *Client) GenerateDocUpdate(ctx context.Context, req UpdateRequest) (stri
 	// Create messages
 	messages := []ChatMessage{
 		{
-			Role:    "system",
+			Role:    llm.RoleSystem,
 			Content: "You are a technical documentation updater. Follow the rules exactly and output the complete updated document wrapped in <updated_document> tags.",
 		},
 		{
-			Role:    "user",
+			Role:    llm.RoleUser,
 			Content: prompt,
 		},
 	}
 
-	// Call OpenAI API
-	response, err := c.CreateChatCompletion(ctx, messages)
+	response, err := provider.ChatCompletion(ctx, llm.Request{Messages: messages})
 	if err != nil {
-		return "", fmt.Errorf("openai api call failed: %w", err)
+		return "", fmt.Errorf("%s api call failed: %w", provider.Name(), err)
 	}
 
 	return response, nil
@@ -104,8 +111,11 @@ func buildUpdatePrompt(req UpdateRequest) (string, error) {
 		CurrentContent:      req.CurrentContent,
 		UpdateInstruction:   req.UpdateInstruction,
 		ContentType:         req.ContentType,
+		DocFormat:           req.DocFormat,
 		StyleGuide:          req.StyleGuide,
 		UpdateType:          req.UpdateType,
+		Structure:           req.Structure,
+		TabsImported:        req.TabsImported,
 	}
 
 	var buf bytes.Buffer
@@ -0,0 +1,41 @@
+package openai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildUpdatePromptTabs(t *testing.T) {
+	const tabsRule = "wrap it in <Tabs> with a <TabItem> for each label"
+	structure := "Tab groups (1): [Kotlin | Swift] x1"
+	tests := []struct {
+		name         string
+		format       string
+		structure    string
+		tabsImported bool
+		want         bool
+	}{
+		{name: "tabs imported", format: "mdx", structure: "Imports: Tabs (@theme/Tabs); TabItem (@theme/TabItem)\n" + structure, tabsImported: true, want: true},
+		{name: "tabs not imported", format: "mdx", structure: structure, want: false},
+		{name: "markdown", format: "md", structure: "Admonitions: :::note x1", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt, err := buildUpdatePrompt(UpdateRequest{
+				DocPath:      "sdk/client-side/android.mdx",
+				DocFormat:    tt.format,
+				Structure:    tt.structure,
+				TabsImported: tt.tabsImported,
+			})
+			if err != nil {
+				t.Fatalf("buildUpdatePrompt() error = %v", err)
+			}
+			if got := strings.Contains(prompt, tabsRule); got != tt.want {
+				t.Errorf("prompt contains the Tabs rule = %v, want %v", got, tt.want)
+			}
+			if !strings.Contains(prompt, "- Keep every existing import, <Tabs> group, <TabItem> and admonition; updates that drop any of them are rejected\n- ") {
+				t.Errorf("prompt lost the line break after the structure rules:\n%s", prompt)
+			}
+		})
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"strings"
 	"text/template"
+
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/llm"
 )
 
 // DocIdentificationPrompt is the prompt template for Phase 1: Document Identification.
@@ -16,6 +18,9 @@ import (
 //go:embed prompts/identify.tmpl
 var DocIdentificationPrompt string
 
+// DefaultMaxFilesToUpdate is the default maximum number of files Phase 1 may select.
+const DefaultMaxFilesToUpdate = 3
+
 // GlossaryEntry represents a term in the glossary.
 type GlossaryEntry struct {
 	Name        string `json:"name"`
@@ -31,6 +36,14 @@ type DocFile struct {
 	Category    string   `json:"category,omitempty"`
 	Audience    string   `json:"audience,omitempty"`
 	ContentType string   `json:"content_type,omitempty"` // user-guide, admin-config, developer-reference
+	Platform    string   `json:"platform,omitempty"`     // SDK platform (e.g. "android")
+	SDKKind     string   `json:"sdk_kind,omitempty"`     // native or openfeature
+	Parent      string   `json:"parent,omitempty"`       // path of the parent page in the sidebar
+	Children    []string `json:"children,omitempty"`     // paths of child pages in the sidebar
+	IsHub       bool     `json:"is_hub,omitempty"`       // overview page or ai_hub: true (rejected as a target)
+	Sections    []string `json:"sections,omitempty"`     // ## and ### headings (e.g. "## Inviting New Members")
+	LinksTo     []string `json:"links_to,omitempty"`     // paths of the docs this doc links to
+	Inbound     int      `json:"inbound,omitempty"`      // number of docs linking to this doc
 }
 
 // DocsManifest represents the list of available documentation files.
@@ -47,8 +60,17 @@ type IdentifyRequest struct {
 	DiffSummary  string // Summary of changed files (not full diff)
 	Glossary     []GlossaryEntry
 	DocsManifest *DocsManifest
+	MaxFiles     int // Maximum files to select (0 = DefaultMaxFilesToUpdate)
 }
 
+// Update types for FileToUpdate.UpdateType.
+const (
+	UpdateTypeAddInline     = "add_inline"
+	UpdateTypeModifySection = "modify_section"
+	UpdateTypeAddSection    = "add_section"
+	UpdateTypeAddExample    = "add_example"
+)
+
 // FileToUpdate represents a file that needs to be updated.
 type FileToUpdate struct {
 	Path             string `json:"path"`
@@ -73,37 +95,49 @@ type identifyTemplateData struct {
 	PRDescription string
 	DiffSummary   string
 	DocsManifest  *DocsManifest
+	MaxFiles      int
 }
 
 // IdentifyDocsToUpdate executes Phase 1: Document Identification.
 // It analyzes the issue/PR context and returns which documentation files need updates.
-func (c *Client) IdentifyDocsToUpdate(ctx context.Context, req IdentifyRequest) (*IdentifyResponse, error) {
+// The prompt works with any llm.Provider; JSON mode is enforced where the provider supports it.
+func IdentifyDocsToUpdate(ctx context.Context, provider llm.Provider, req IdentifyRequest) (*IdentifyResponse, error) {
 	// Build prompt from template
 	prompt, err := buildIdentifyPrompt(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build identify prompt: %w", err)
 	}
 
+	// Request JSON response format to guarantee valid JSON output where
+	// supported; other providers only get the instruction in the prompt
+	jsonMode := provider.SupportsJSONMode()
+	system := "You are a documentation analyst. Respond only with valid JSON."
+	if !jsonMode {
+		system += "\n\n" + llm.JSONOnlyInstruction
+	}
+
 	// Create messages
 	messages := []ChatMessage{
 		{
-			Role:    "system",
-			Content: "You are a documentation analyst. Respond only with valid JSON.",
+			Role:    llm.RoleSystem,
+			Content: system,
 		},
 		{
-			Role:    "user",
+			Role:    llm.RoleUser,
 			Content: prompt,
 		},
 	}
 
-	// Call OpenAI API with JSON response format to guarantee valid JSON output
-	response, err := c.CreateChatCompletion(ctx, messages, WithJSONResponse())
+	response, err := provider.ChatCompletion(ctx, llm.Request{
+		Messages:     messages,
+		JSONResponse: jsonMode,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("openai api call failed: %w", err)
+		return nil, fmt.Errorf("%s api call failed: %w", provider.Name(), err)
 	}
 
 	// Parse response
-	result, err := parseIdentifyResponse(response)
+	result, err := parseIdentifyResponse(response, maxFiles(req))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse identify response: %w", err)
 	}
@@ -113,7 +147,7 @@ func (c *Client) IdentifyDocsToUpdate(ctx context.Context, req IdentifyRequest)
 
 // buildIdentifyPrompt builds the prompt for document identification.
 func buildIdentifyPrompt(req IdentifyRequest) (string, error) {
-	tmpl, err := template.New("identify").Parse(DocIdentificationPrompt)
+	tmpl, err := template.New("identify").Funcs(template.FuncMap{"join": strings.Join}).Parse(DocIdentificationPrompt)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse template: %w", err)
 	}
@@ -135,6 +169,7 @@ func buildIdentifyPrompt(req IdentifyRequest) (string, error) {
 		PRDescription: sanitized.PRBody,
 		DiffSummary:   req.DiffSummary,
 		DocsManifest:  req.DocsManifest,
+		MaxFiles:      maxFiles(req),
 	}
 
 	var buf bytes.Buffer
@@ -145,8 +180,16 @@ func buildIdentifyPrompt(req IdentifyRequest) (string, error) {
 	return buf.String(), nil
 }
 
+// maxFiles returns the file limit for a request, applying the default.
+func maxFiles(req IdentifyRequest) int {
+	if req.MaxFiles > 0 {
+		return req.MaxFiles
+	}
+	return DefaultMaxFilesToUpdate
+}
+
 // parseIdentifyResponse parses the AI response into IdentifyResponse.
-func parseIdentifyResponse(response string) (*IdentifyResponse, error) {
+func parseIdentifyResponse(response string, maxFiles int) (*IdentifyResponse, error) {
 	// Try to extract JSON from the response
 	// The response might contain markdown code blocks
 	jsonStr := extractJSON(response)
@@ -161,9 +204,9 @@ func parseIdentifyResponse(response string) (*IdentifyResponse, error) {
 		return nil, fmt.Errorf("invalid response: needs_update is true but no files_to_update")
 	}
 
-	// Limit to maximum 3 files
-	if len(result.FilesToUpdate) > 3 {
-		result.FilesToUpdate = result.FilesToUpdate[:3]
+	// Limit to maximum files
+	if len(result.FilesToUpdate) > maxFiles {
+		result.FilesToUpdate = result.FilesToUpdate[:maxFiles]
 	}
 
 	return &result, nil
@@ -0,0 +1,73 @@
+package openai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/llm"
+)
+
+// fakeProvider records the last request and returns a fixed reply.
+type fakeProvider struct {
+	jsonMode bool
+	reply    string
+	last     llm.Request
+}
+
+func (p *fakeProvider) Name() string           { return "fake" }
+func (p *fakeProvider) Model() string          { return "fake-model" }
+func (p *fakeProvider) SupportsJSONMode() bool { return p.jsonMode }
+func (p *fakeProvider) Usage() llm.Usage       { return llm.Usage{} }
+
+func (p *fakeProvider) ChatCompletion(_ context.Context, req llm.Request) (string, error) {
+	p.last = req
+	return p.reply, nil
+}
+
+func TestIdentifyDocsToUpdateJSONMode(t *testing.T) {
+	tests := []struct {
+		name            string
+		jsonMode        bool
+		reply           string
+		wantInstruction bool
+	}{
+		{
+			name:     "native JSON mode",
+			jsonMode: true,
+			reply:    `{"needs_update": true, "reason": "new flag", "files_to_update": [{"path": "a.md", "update_type": "add_inline"}]}`,
+		},
+		{
+			name:            "prompt-only JSON",
+			jsonMode:        false,
+			reply:           "```json\n{\"needs_update\": true, \"reason\": \"new flag\", \"files_to_update\": [{\"path\": \"a.md\", \"update_type\": \"add_inline\"}]}\n```",
+			wantInstruction: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &fakeProvider{jsonMode: tt.jsonMode, reply: tt.reply}
+			got, err := IdentifyDocsToUpdate(context.Background(), p, IdentifyRequest{
+				IssueTitle:   "Add flag triggers",
+				DocsManifest: &DocsManifest{Files: []DocFile{{Path: "a.md", Title: "A"}}},
+			})
+			if err != nil {
+				t.Fatalf("IdentifyDocsToUpdate() error = %v", err)
+			}
+			if !got.NeedsUpdate || len(got.FilesToUpdate) != 1 || got.FilesToUpdate[0].Path != "a.md" {
+				t.Errorf("IdentifyDocsToUpdate() = %+v", got)
+			}
+
+			if p.last.JSONResponse != tt.jsonMode {
+				t.Errorf("JSONResponse = %v, want %v", p.last.JSONResponse, tt.jsonMode)
+			}
+			system := p.last.Messages[0]
+			if system.Role != llm.RoleSystem {
+				t.Fatalf("first message role = %q, want system", system.Role)
+			}
+			if got := strings.Contains(system.Content, llm.JSONOnlyInstruction); got != tt.wantInstruction {
+				t.Errorf("system prompt has the JSON-only instruction = %v, want %v", got, tt.wantInstruction)
+			}
+		})
+	}
+}
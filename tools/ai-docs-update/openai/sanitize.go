@@ -1,4 +1,5 @@
 // Package openai provides OpenAI API client and prompt handling for AI-driven documentation updates.
+// The identify and generate prompts run against any llm.Provider; Client is the OpenAI implementation.
 package openai
 
 import (
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"strings"
+
+	appconfig "github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/config"
+	appctx "github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/context"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/docs"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/glossary"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/guardrails"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/llm"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/openai"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/report"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/retrieval"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/styleguide"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/unidiff"
+)
+
+// inputs is the context shared by both phases.
+type inputs struct {
+	issue           *appctx.IssueContext
+	pr              *appctx.PRContext
+	glossary        []glossary.Entry
+	styleGuide      string // formatted style guide rules
+	styleGuideRules int
+	route           docs.PlatformRoute // SDK platforms the change is limited to (empty = any doc)
+	filteredPaths   []string           // changed files removed from the diff by the source path filters
+	changedPaths    []string           // changed files kept by the filters, read before the diff is summarized
+	diffSummary     string             // Phase 1 list of the changed files, built before the diff is summarized
+	redactions      map[string]int     // secrets redacted from the issue and PR context, by kind
+}
+
+// loadInputs loads the issue and PR context, plus the optional glossary and style guide.
+// Secrets are redacted from the context as soon as it is loaded; errors wrapping
+// guardrails.ErrSecretsDetected mean redaction is set to fail closed.
+func loadInputs(ctx context.Context, cfg config, inputGuard *guardrails.InputGuardrails) (*inputs, error) {
+	// 1-2. Load issue and PR context (from files or GitHub)
+	source, err := newSource(cfg)
+	if err != nil {
+		return nil, err
+	}
+	issueCtx, prCtx, err := source.Fetch(ctx, cfg.issueNumber, cfg.prNumbers)
+	if err != nil {
+		return nil, err
+	}
+
+	// Redact secrets before anything is logged, reported or sent to the LLM
+	redactions, err := inputGuard.RedactContext(issueCtx, prCtx)
+	if err != nil {
+		log.Printf("Secret guardrails triggered (failing closed): %v", err)
+		return nil, err
+	}
+	if len(redactions) > 0 {
+		log.Printf("Redacted secrets from the issue and PR context: %s", guardrails.FormatRedactions(redactions))
+	}
+	log.Printf("Issue: %q", issueCtx.Title)
+	log.Printf("PR: %q", prCtx.Title)
+
+	// 3. Load glossary (optional - continue without it if loading fails)
+	glossaryEntries, err := glossary.Load(cfg.glossaryFile)
+	if err != nil {
+		log.Printf("Warning: Failed to load glossary: %v (continuing without glossary)", err)
+		glossaryEntries = nil
+	} else {
+		log.Printf("Loaded %d glossary entries", len(glossaryEntries))
+	}
+
+	// 3.5. Load style guide (optional - continue with defaults if loading fails)
+	styleGuideData, err := styleguide.Load(cfg.settings.StyleGuidePath())
+	if err != nil {
+		log.Printf("Warning: Failed to load style guide: %v (using defaults)", err)
+	} else {
+		log.Printf("Loaded %d style guide rules", styleGuideData.RuleCount())
+	}
+
+	route := platformRoute(cfg.settings, prCtx)
+	if len(route) > 0 {
+		log.Printf("SDK change: routing to %s docs", route)
+	}
+
+	return &inputs{
+		issue:           issueCtx,
+		pr:              prCtx,
+		glossary:        glossaryEntries,
+		styleGuide:      styleGuideData.Format(),
+		styleGuideRules: styleGuideData.RuleCount(),
+		route:           route,
+		redactions:      redactions,
+	}, nil
+}
+
+// platformRoute returns the SDK platforms the pull requests document. Changes
+// are only routed when every pull request comes from an SDK repository, since
+// a change to any other repository may touch any doc. Context loaded from
+// files is attributed to the configured source repository.
+func platformRoute(settings *appconfig.Config, pr *appctx.PRContext) docs.PlatformRoute {
+	if len(pr.PRs) == 0 {
+		return settings.PlatformRoute(settings.Source.Repo, changedPaths(pr.Diff))
+	}
+
+	var route docs.PlatformRoute
+	for _, p := range pr.PRs {
+		repo := repoFromURL(p.URL)
+		if repo == "" {
+			repo = settings.Source.Repo
+		}
+		r := settings.PlatformRoute(repo, changedPaths(p.Diff))
+		if len(r) == 0 {
+			return nil
+		}
+		for _, t := range r {
+			if !slices.Contains(route, t) {
+				route = append(route, t)
+			}
+		}
+	}
+	return route
+}
+
+// repoFromURL returns "owner/name" from a pull request URL
+// (https://github.com/owner/name/pull/1), or "" if it has another form.
+func repoFromURL(prURL string) string {
+	u, err := url.Parse(prURL)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 4 || parts[2] != "pull" {
+		return ""
+	}
+	return parts[0] + "/" + parts[1]
+}
+
+// changedPaths returns the paths of the files changed in a diff, with both
+// paths of renamed and copied files.
+func changedPaths(diff string) []string {
+	var paths []string
+	for _, f := range unidiff.Parse(diff).Files {
+		if f.OldPath != "" && f.OldPath != f.NewPath {
+			paths = append(paths, f.OldPath)
+		}
+		if f.NewPath != "" {
+			paths = append(paths, f.NewPath)
+		}
+	}
+	return paths
+}
+
+// checkInputs runs the input guardrails and summarizes a large diff in place.
+// A non-empty reason means the run should be skipped; this is expected behavior,
+// not a failure.
+func checkInputs(in *inputs, inputGuard *guardrails.InputGuardrails, maxDiffBytes int) (report.Reason, error) {
+	if err := inputGuard.ValidateContext(in.issue, in.pr); err != nil {
+		log.Printf("Input guardrails triggered (skipping): %v", err)
+		return report.ReasonInputGuardrails, err
+	}
+
+	// Drop generated code, lockfiles and other filtered paths, then
+	// validate structure and summarize large diffs
+	in.pr.Diff, in.filteredPaths = inputGuard.FilterDiff(in.pr.Diff)
+	if len(in.filteredPaths) > 0 {
+		log.Printf("Filtered %d changed files from the diff: %s", len(in.filteredPaths), strings.Join(in.filteredPaths, ", "))
+	}
+
+	if in.pr.Diff != "" {
+		// A summarized diff loses its file headers: validate the changed files
+		// and keep their paths and summary for Phase 1 first
+		parsedDiff := unidiff.Parse(in.pr.Diff)
+		if err := inputGuard.ValidateDiff(parsedDiff); err != nil {
+			log.Printf("Diff guardrails triggered (skipping): %v", err)
+			return report.ReasonDiffGuardrails, err
+		}
+		log.Printf("Diff validated: %d files, %d bytes", len(parsedDiff.Files), parsedDiff.Size)
+		in.changedPaths = changedPaths(in.pr.Diff)
+		in.diffSummary = guardrails.SummarizeDiff(in.pr.Diff)
+
+		// Summarize if diff is too large
+		originalSize := len(in.pr.Diff)
+		in.pr.Diff = guardrails.SummarizeLargeDiff(in.pr.Diff, maxDiffBytes)
+		if len(in.pr.Diff) < originalSize {
+			log.Printf("Large diff summarized: %d → %d bytes", originalSize, len(in.pr.Diff))
+		}
+	}
+	return "", nil
+}
+
+// identify runs Phase 1 and returns the response with the prompt token estimate.
+// Errors wrapping guardrails.ErrTokenLimitExceeded mean the prompt was never sent.
+func identify(
+	ctx context.Context,
+	provider llm.Provider,
+	in *inputs,
+	manifest *docs.Manifest,
+	inputGuard *guardrails.InputGuardrails,
+	maxFiles int,
+) (*openai.IdentifyResponse, int, error) {
+	// Token limit check for Phase 1 (includes glossary, manifest, diff summary)
+	tokens := estimatePhase1Tokens(inputGuard, in.issue, in.pr, in.glossary, manifest, in.diffSummary)
+	if tokens > inputGuard.MaxInputTokens {
+		log.Printf("Phase 1 token limit exceeded: ~%d tokens (max %d)", tokens, inputGuard.MaxInputTokens)
+		return nil, tokens, fmt.Errorf("%w: phase 1 context ~%d tokens", guardrails.ErrTokenLimitExceeded, tokens)
+	}
+	log.Printf("Phase 1 token estimate: ~%d tokens", tokens)
+
+	identification, err := openai.IdentifyDocsToUpdate(ctx, provider, openai.IdentifyRequest{
+		IssueTitle:   in.issue.Title,
+		IssueBody:    in.issue.Body,
+		PRTitle:      in.pr.Title,
+		PRBody:       in.pr.Body,
+		DiffSummary:  in.diffSummary, // changed files list (efficient format, not full diff)
+		Glossary:     toOpenAIGlossary(in.glossary),
+		DocsManifest: toOpenAIDocsManifest(manifest),
+		MaxFiles:     maxFiles,
+	})
+	if err != nil {
+		return nil, tokens, fmt.Errorf("failed to identify docs: %w", err)
+	}
+	return identification, tokens, nil
+}
+
+// rejectedTarget is a Phase 1 selection removed by checkTargets.
+type rejectedTarget struct {
+	file   openai.FileToUpdate
+	reason report.Reason
+	err    error
+}
+
+// checkTargets removes the selected files that must not be updated and returns
+// them: hub pages (the prompt already tells the model to avoid them; this
+// enforces it), docs outside the route's SDK platforms, and targets naming
+// headings the doc does not have. Close heading matches are corrected in
+// place. If no file is left, the response becomes a no-update response.
+func checkTargets(manifest *docs.Manifest, route docs.PlatformRoute, identification *openai.IdentifyResponse) []rejectedTarget {
+	var kept []openai.FileToUpdate
+	var rejected []rejectedTarget
+	for _, f := range identification.FilesToUpdate {
+		if reason, err := checkTarget(manifest, route, &f); err != nil {
+			log.Printf("Rejected %s: %v", f.Path, err)
+			rejected = append(rejected, rejectedTarget{file: f, reason: reason, err: err})
+			continue
+		}
+		kept = append(kept, f)
+	}
+
+	identification.FilesToUpdate = kept
+	if identification.NeedsUpdate && len(kept) == 0 {
+		identification.NeedsUpdate = false
+		identification.Reason = "all selected files were rejected: " + identification.Reason
+	}
+	return rejected
+}
+
+// checkTarget checks one selected file, correcting its target location if needed.
+// Paths missing from the manifest are left to Phase 2, which reports them.
+func checkTarget(manifest *docs.Manifest, route docs.PlatformRoute, f *openai.FileToUpdate) (report.Reason, error) {
+	doc := manifest.FindFile(f.Path)
+	if doc == nil {
+		return "", nil
+	}
+	if doc.IsHub {
+		return report.ReasonHubPage, fmt.Errorf("%w: %s", docs.ErrHubPage, f.Path)
+	}
+	if !route.Allows(doc) {
+		return report.ReasonPlatformMismatch, fmt.Errorf("%w: %s (routed to %s)", docs.ErrPlatformMismatch, f.Path, route)
+	}
+
+	target, err := resolveTargetLocation(doc, f.UpdateType, f.TargetLocation)
+	if err != nil {
+		return report.ReasonTargetNotFound, err
+	}
+	if target != f.TargetLocation {
+		log.Printf("Corrected target for %s: %q -> %q", f.Path, f.TargetLocation, target)
+		f.TargetLocation = target
+	}
+	return "", nil
+}
+
+// resolveTargetLocation checks the headings a target location names against
+// the doc's outline and returns the target with close matches replaced by the
+// actual heading text. add_section targets may name the heading being added,
+// so a missing heading is only an error for the other update types.
+func resolveTargetLocation(doc *docs.DocFile, updateType, target string) (string, error) {
+	if len(doc.Outline) == 0 {
+		return target, nil // nothing to check against (e.g. a manifest saved without outlines)
+	}
+
+	for _, ref := range doc.HeadingRefs(target) {
+		h, exact := doc.FindHeading(ref)
+		switch {
+		case h == nil && updateType == openai.UpdateTypeAddSection:
+		case h == nil:
+			return "", fmt.Errorf("%w: %q in %s", docs.ErrTargetNotFound, ref, doc.Path)
+		case !exact:
+			target = strings.Replace(target, ref, h.Text, 1)
+		}
+	}
+	return target, nil
+}
+
+// selectCandidates ranks the manifest against the issue, PR text and changed
+// file paths and returns a manifest of the topN docs (in manifest order) for
+// Phase 1. The whole manifest is returned if topN is 0, the manifest is not
+// larger than topN, or nothing in the query matches. An SDK change only
+// considers the docs of its platforms; if there are none, the route is
+// dropped and all docs are considered.
+func selectCandidates(in *inputs, manifest *docs.Manifest, docsDir string, topN int) *docs.Manifest {
+	if len(in.route) > 0 {
+		routed := in.route.Filter(manifest)
+		if len(routed.Files) == 0 {
+			log.Printf("Warning: No docs for SDK platform %s (considering all docs)", in.route)
+			in.route = nil
+		} else {
+			log.Printf("Routed to %d of %d docs for SDK platform %s", len(routed.Files), len(manifest.Files), in.route)
+			manifest = routed
+		}
+	}
+
+	if topN <= 0 || len(manifest.Files) <= topN {
+		return manifest
+	}
+
+	results := retrieval.NewIndex(manifest, docsDir).Rank(retrievalQuery(in))
+	if len(results) == 0 || results[0].Score == 0 {
+		log.Printf("Pre-ranking found no matching docs; sending all %d docs to Phase 1", len(manifest.Files))
+		return manifest
+	}
+
+	keep := make(map[string]bool, topN)
+	for _, r := range results[:topN] {
+		keep[r.Path] = true
+	}
+	candidates := &docs.Manifest{SchemaVersion: manifest.SchemaVersion}
+	for _, f := range manifest.Files {
+		if keep[f.Path] {
+			candidates.Files = append(candidates.Files, f)
+		}
+	}
+
+	log.Printf("Pre-ranked %d docs; sending the top %d to Phase 1", len(manifest.Files), len(candidates.Files))
+	for _, r := range results[:min(5, topN)] {
+		log.Printf("  - %s (%.2f)", r.Path, r.Score)
+	}
+	return candidates
+}
+
+// retrievalQuery returns the text docs are ranked against: the issue and PR
+// titles and bodies and the paths of the changed files (all of them, even if
+// the diff was summarized).
+func retrievalQuery(in *inputs) string {
+	var sb strings.Builder
+	for _, s := range []string{in.issue.Title, in.issue.Body, in.pr.Title, in.pr.Body} {
+		sb.WriteString(s)
+		sb.WriteString("\n")
+	}
+	for _, path := range in.changedPaths {
+		sb.WriteString(path)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
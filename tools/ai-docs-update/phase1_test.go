@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	appctx "github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/context"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/docs"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/guardrails"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/openai"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/report"
+)
+
+func TestResolveTargetLocation(t *testing.T) {
+	doc := &docs.DocFile{
+		Path: "getting-started/ai-assistant.mdx",
+		Outline: docs.ParseOutline(`## Using the AI Assistant
+
+### Asking a question
+
+## What the AI Assistant can and can't do
+
+## Adding, editing, and deleting
+
+## Troubleshooting
+`),
+	}
+	tests := []struct {
+		name       string
+		updateType string
+		target     string
+		want       string
+		wantErr    error
+	}{
+		{
+			name:       "apostrophe in heading",
+			updateType: openai.UpdateTypeAddInline,
+			target:     "In '## What the AI Assistant can and can't do' section, after the list",
+			want:       "In '## What the AI Assistant can and can't do' section, after the list",
+		},
+		{
+			name:       "commas in heading",
+			updateType: openai.UpdateTypeModifySection,
+			target:     "In the '## Adding, editing, and deleting' section",
+			want:       "In the '## Adding, editing, and deleting' section",
+		},
+		{
+			name:       "close match is corrected",
+			updateType: openai.UpdateTypeAddInline,
+			target:     "In '### Ask a question' section",
+			want:       "In '### Asking a question' section",
+		},
+		{
+			name:       "missing heading",
+			updateType: openai.UpdateTypeAddInline,
+			target:     "In '## Billing' section",
+			wantErr:    docs.ErrTargetNotFound,
+		},
+		{
+			name:       "missing heading with an apostrophe",
+			updateType: openai.UpdateTypeModifySection,
+			target:     "In '## What you can't configure' section",
+			wantErr:    docs.ErrTargetNotFound,
+		},
+		{
+			name:       "new section may name a missing heading",
+			updateType: openai.UpdateTypeAddSection,
+			target:     "After '## Troubleshooting', add '## Can't see the assistant?' section",
+			want:       "After '## Troubleshooting', add '## Can't see the assistant?' section",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveTargetLocation(doc, tt.updateType, tt.target)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("resolveTargetLocation() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveTargetLocation() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetrievalQueryAfterSummarizedDiff(t *testing.T) {
+	var diff strings.Builder
+	paths := []string{"pkg/feature/evaluator.go", "docs/sdk/android.md", "ui/web/src/pages/flags.tsx"}
+	for _, path := range paths {
+		fmt.Fprintf(&diff, "diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n@@ -1,1 +1,200 @@\n", path, path, path, path)
+		for i := 0; i < 200; i++ {
+			fmt.Fprintf(&diff, "+line %d of %s\n", i, path)
+		}
+	}
+	diff.WriteString("diff --git a/go.sum b/go.sum\n--- a/go.sum\n+++ b/go.sum\n@@ -1 +1 @@\n-a v1\n+a v2\n")
+
+	in := &inputs{
+		issue: &appctx.IssueContext{Title: "Evaluate flags", Body: "body"},
+		pr:    &appctx.PRContext{Title: "Add evaluator", Diff: diff.String()},
+	}
+	g := guardrails.NewInputGuardrails()
+	if reason, err := checkInputs(in, g, 2048); reason != "" {
+		t.Fatalf("checkInputs() = %s, %v", reason, err)
+	}
+	if len(in.pr.Diff) > 2048 {
+		t.Fatalf("diff is %d bytes, want it summarized to 2048", len(in.pr.Diff))
+	}
+
+	query := retrievalQuery(in)
+	for _, path := range paths {
+		if !strings.Contains(query, path+"\n") {
+			t.Errorf("retrievalQuery() is missing changed path %s", path)
+		}
+		if !strings.Contains(in.diffSummary, "- "+path+" (") {
+			t.Errorf("Phase 1 diff summary is missing changed path %s:\n%s", path, in.diffSummary)
+		}
+	}
+	if strings.Contains(query, "go.sum") {
+		t.Errorf("retrievalQuery() contains the filtered go.sum")
+	}
+
+	// The diff guardrails see the changed files before they are summarized
+	in.pr.Diff = diff.String()
+	g.MaxChangedFiles = 2
+	if reason, err := checkInputs(in, g, 2048); reason != report.ReasonDiffGuardrails || !errors.Is(err, guardrails.ErrTooManyFiles) {
+		t.Errorf("checkInputs() with %d changed files = %s, %v; want %s", len(paths), reason, err, report.ReasonDiffGuardrails)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/docs"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/guardrails"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/llm"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/openai"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/report"
+)
+
+// writeReserve is the time kept back from the app timeout for writing files
+// and reports after all generations finish.
+const writeReserve = 15 * time.Second
+
+// generation is the result of generating one file update.
+// Exactly one of content or reason is set.
+type generation struct {
+	update   openai.FileToUpdate
+	content  string   // post-processed document content
+	warnings []string // post-process warnings
+	tokens   int      // estimated prompt tokens (0 if generation stopped earlier)
+	reason   report.Reason
+	err      error
+}
+
+// skipped reports whether the generation failed or was rejected.
+func (g generation) skipped() bool {
+	return g.reason != ""
+}
+
+// generator produces Phase 2 updates. Generations run concurrently and share
+// the generator, so it must not be modified after creation.
+type generator struct {
+	provider    llm.Provider
+	inputs      *inputs
+	manifest    *docs.Manifest
+	docsDir     string
+	inputGuard  *guardrails.InputGuardrails
+	outputGuard *guardrails.OutputGuardrails
+}
+
+// generate reads, regenerates and validates a single file.
+func (g *generator) generate(ctx context.Context, fileUpdate openai.FileToUpdate) generation {
+	result := generation{update: fileUpdate}
+	log.Printf("Processing: %s (%s)", fileUpdate.Path, fileUpdate.UpdateType)
+	issueCtx, prCtx := g.inputs.issue, g.inputs.pr
+
+	// Build full path for reading (docsDir + relative path from manifest)
+	fullPath := filepath.Join(g.docsDir, fileUpdate.Path)
+
+	// Look up content type from manifest
+	contentType := findContentType(g.manifest, fileUpdate.Path)
+
+	// Read current content
+	currentContent, err := docs.ReadFile(fullPath)
+	if err != nil {
+		log.Printf("ERROR: Failed to read %s: %v (skipping)", fileUpdate.Path, err)
+		result.reason, result.err = report.ReasonReadFailed, err
+		return result
+	}
+
+	// Validate document content size
+	if err := g.inputGuard.ValidateDocContent(currentContent); err != nil {
+		log.Printf("Document too large for %s (skipping): %v", fileUpdate.Path, err)
+		result.reason, result.err = reasonFromError(err), err
+		return result
+	}
+
+	// Token limit check
+	combinedContext := issueCtx.String() + prCtx.String()
+	result.tokens = g.inputGuard.CountTokens(combinedContext) + g.inputGuard.CountTokens(currentContent)
+	if err := g.inputGuard.ValidateTokenLimit(combinedContext, currentContent); err != nil {
+		log.Printf("Token limit exceeded for %s (skipping): %v", fileUpdate.Path, err)
+		result.reason, result.err = reasonFromError(err), err
+		return result
+	}
+
+	// Generate update (Full file content)
+	structure := docs.ParseStructure(currentContent)
+	rawResult, err := openai.GenerateDocUpdate(ctx, g.provider, openai.UpdateRequest{
+		IssueTitle:        issueCtx.Title,
+		IssueBody:         issueCtx.Body,
+		PRTitle:           prCtx.Title,
+		PRBody:            prCtx.Body,
+		CodeDiff:          prCtx.Diff,
+		Glossary:          toOpenAIGlossary(g.inputs.glossary),
+		DocPath:           fileUpdate.Path,
+		CurrentContent:    currentContent,
+		UpdateInstruction: fileUpdate.BriefDescription,
+		ContentType:       contentType,
+		DocFormat:         string(docs.FormatOf(fileUpdate.Path)),
+		StyleGuide:        g.inputs.styleGuide,
+		UpdateType:        fileUpdate.UpdateType,
+		Structure:         structure.Describe(),
+		TabsImported:      structure.ImportsTabs(),
+	})
+	if err != nil {
+		log.Printf("ERROR: %s error for %s: %v (skipping)", g.provider.Name(), fileUpdate.Path, err)
+		result.reason, result.err = report.ReasonLLMError, err
+		return result
+	}
+
+	// Output guardrails validation
+	if err := g.outputGuard.Validate(rawResult); err != nil {
+		log.Printf("Output guardrails triggered for %s: %v (skipping)", fileUpdate.Path, err)
+		result.reason, result.err = reasonFromError(err), err
+		return result
+	}
+
+	// Extract document content
+	content := guardrails.ExtractDocumentContent(rawResult)
+	if content == "" {
+		log.Printf("ERROR: Failed to extract content for %s (skipping)", fileUpdate.Path)
+		result.reason, result.err = report.ReasonEmptyContent, guardrails.ErrEmptyContent
+		return result
+	}
+
+	// Apply post-processing transformations (placeholder→TODO, non-ASCII→ASCII)
+	content, postProcessWarnings := g.outputGuard.PostProcess(content)
+	for _, w := range postProcessWarnings {
+		log.Printf("Post-process warning for %s: %s", fileUpdate.Path, w)
+	}
+	// Imports, tab groups and admonitions must survive the update
+	if err := docs.CheckStructure(structure, docs.ParseStructure(content)); err != nil {
+		log.Printf("Structure check failed for %s: %v (skipping)", fileUpdate.Path, err)
+		result.reason, result.err = reasonFromError(err), err
+		return result
+	}
+
+	result.content = content
+	result.warnings = postProcessWarnings
+	return result
+}
+
+// generateFunc generates the update for a single file.
+type generateFunc func(ctx context.Context, update openai.FileToUpdate) generation
+
+// generateAll runs generate for every update with at most workers in parallel.
+// Each call gets its own deadline derived from the remaining context budget.
+// Results are returned in the same order as updates regardless of completion order.
+func generateAll(ctx context.Context, updates []openai.FileToUpdate, workers int, generate generateFunc) []generation {
+	if workers < 1 {
+		workers = 1
+	}
+	timeout := perFileTimeout(ctx, len(updates), workers)
+	if timeout > 0 {
+		log.Printf("Generating %d files with %d workers (per-file deadline: %s)",
+			len(updates), workers, timeout.Round(time.Second))
+	}
+
+	results := make([]generation, len(updates))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	for i, update := range updates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			fileCtx, cancel := ctx, context.CancelFunc(func() {})
+			if timeout > 0 {
+				fileCtx, cancel = context.WithTimeout(ctx, timeout)
+			}
+			defer cancel()
+
+			results[i] = generate(fileCtx, update)
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
+
+// perFileTimeout splits the remaining context budget (minus writeReserve)
+// evenly across the sequential batches the worker pool will run.
+// Returns 0, meaning no per-file deadline, if ctx has no deadline or no
+// more than writeReserve is left; the calls then run until ctx is done.
+func perFileTimeout(ctx context.Context, files, workers int) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok || files == 0 {
+		return 0
+	}
+	batches := (files + workers - 1) / workers
+	remaining := time.Until(deadline) - writeReserve
+	if remaining <= 0 {
+		// Out of budget; let the parent deadline cancel the calls
+		return 0
+	}
+	return remaining / time.Duration(batches)
+}
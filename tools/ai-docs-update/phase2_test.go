@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/openai"
+)
+
+func TestPerFileTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		budget  time.Duration // 0 = no deadline
+		files   int
+		workers int
+		want    time.Duration
+	}{
+		{name: "no deadline", files: 3, workers: 1, want: 0},
+		{name: "no files", budget: writeReserve + time.Minute, files: 0, workers: 1, want: 0},
+		{name: "one batch", budget: writeReserve + time.Minute, files: 3, workers: 4, want: time.Minute},
+		{name: "partial last batch", budget: writeReserve + time.Minute, files: 5, workers: 2, want: 20 * time.Second},
+		{name: "budget exhausted", budget: writeReserve / 2, files: 2, workers: 1, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.budget > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.budget)
+				defer cancel()
+			}
+			got := perFileTimeout(ctx, tt.files, tt.workers)
+			if got > tt.want || got < tt.want-time.Second {
+				t.Errorf("perFileTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateAll(t *testing.T) {
+	tests := []struct {
+		name    string
+		budget  time.Duration // 0 = no deadline
+		files   int
+		workers int
+	}{
+		{name: "sequential", budget: writeReserve + 30*time.Second, files: 3, workers: 1},
+		{name: "worker pool", budget: writeReserve + 30*time.Second, files: 7, workers: 3},
+		{name: "more workers than files", budget: writeReserve + 30*time.Second, files: 2, workers: 5},
+		{name: "no deadline", files: 4, workers: 2},
+		{name: "budget exhausted", budget: writeReserve / 2, files: 3, workers: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.budget > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.budget)
+				defer cancel()
+			}
+			parentDeadline, hasDeadline := ctx.Deadline()
+			timeout := perFileTimeout(ctx, tt.files, tt.workers)
+
+			updates := make([]openai.FileToUpdate, tt.files)
+			for i := range updates {
+				updates[i] = openai.FileToUpdate{Path: fmt.Sprintf("doc-%d.mdx", i)}
+			}
+
+			var mu sync.Mutex
+			running, maxRunning := 0, 0
+			var completed []string
+			generate := func(ctx context.Context, update openai.FileToUpdate) generation {
+				mu.Lock()
+				running++
+				maxRunning = max(maxRunning, running)
+				mu.Unlock()
+
+				// Each call's deadline is its share of the budget, or the parent's
+				deadline, ok := ctx.Deadline()
+				switch {
+				case timeout > 0:
+					if want := time.Now().Add(timeout); !ok || deadline.After(want) || deadline.Before(want.Add(-time.Second)) {
+						t.Errorf("%s: deadline = %v, want about %v", update.Path, deadline, want)
+					}
+				case ok != hasDeadline || !deadline.Equal(parentDeadline):
+					t.Errorf("%s: deadline = %v, want the parent's (%v)", update.Path, deadline, parentDeadline)
+				}
+
+				// Later files finish first
+				var index int
+				fmt.Sscanf(update.Path, "doc-%d.mdx", &index)
+				time.Sleep(time.Duration(tt.files-index) * 5 * time.Millisecond)
+
+				mu.Lock()
+				running--
+				completed = append(completed, update.Path)
+				mu.Unlock()
+				return generation{update: update, content: "updated " + update.Path}
+			}
+
+			results := generateAll(ctx, updates, tt.workers, generate)
+
+			if want := min(tt.workers, tt.files); maxRunning != want {
+				t.Errorf("max concurrent calls = %d, want %d", maxRunning, want)
+			}
+			if len(completed) != tt.files {
+				t.Fatalf("%d calls completed, want %d", len(completed), tt.files)
+			}
+			if tt.workers > 1 && completed[0] == updates[0].Path {
+				t.Errorf("calls completed in order %v, want later files first", completed)
+			}
+			if len(results) != tt.files {
+				t.Fatalf("generateAll() returned %d results, want %d", len(results), tt.files)
+			}
+			for i, r := range results {
+				if r.update.Path != updates[i].Path || r.content != "updated "+updates[i].Path {
+					t.Errorf("results[%d] = %s (%q), want %s in input order", i, r.update.Path, r.content, updates[i].Path)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package report
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Markdown renders the report as Markdown.
+func (r *Report) Markdown() string {
+	var sb strings.Builder
+
+	sb.WriteString("## AI Docs Update Report\n\n")
+	fmt.Fprintf(&sb, "- **Status:** %s", r.Status)
+	if r.Reason != "" {
+		fmt.Fprintf(&sb, " (%s)", r.Reason)
+	}
+	sb.WriteString("\n")
+	if r.Error != "" {
+		fmt.Fprintf(&sb, "- **Error:** %s\n", escapeCell(r.Error))
+	}
+	if r.Model != "" {
+		fmt.Fprintf(&sb, "- **Model:** %s", r.Model)
+		if r.Provider != "" {
+			fmt.Fprintf(&sb, " (%s)", r.Provider)
+		}
+		sb.WriteString("\n")
+	}
+	if r.DryRun {
+		sb.WriteString("- **Dry run:** yes\n")
+	}
+	fmt.Fprintf(&sb, "- **Files:** %d candidates, %d updated, %d diffed, %d skipped\n",
+		r.Counts.Candidates, r.Counts.Updated, r.Counts.Diffed, r.Counts.Skipped)
+
+	if len(r.FilteredPaths) > 0 {
+		sb.WriteString("\n### Filtered Source Files\n\n")
+		sb.WriteString("Left out of the diff by the source path filters (`diff.include` / `diff.exclude`):\n\n")
+		for _, p := range r.FilteredPaths {
+			fmt.Fprintf(&sb, "- `%s`\n", p)
+		}
+	}
+
+	if len(r.Redactions) > 0 {
+		sb.WriteString("\n### Redacted Secrets\n\n")
+		sb.WriteString("Replaced with placeholders in the issue and PR context before prompting:\n\n")
+		for _, kind := range slices.Sorted(maps.Keys(r.Redactions)) {
+			fmt.Fprintf(&sb, "- %s: %d\n", kind, r.Redactions[kind])
+		}
+	}
+
+	if r.Identify != nil {
+		sb.WriteString("\n### Identification\n\n")
+		fmt.Fprintf(&sb, "Needs update: %t\n\n", r.Identify.NeedsUpdate)
+		switch {
+		case len(r.Identify.Platforms) > 0:
+			fmt.Fprintf(&sb, "Docs considered: %d of %d (SDK platform: %s)\n\n", r.Identify.PromptDocs, r.Identify.ManifestDocs, strings.Join(r.Identify.Platforms, ", "))
+		case r.Identify.PromptDocs < r.Identify.ManifestDocs:
+			fmt.Fprintf(&sb, "Docs considered: top %d of %d (pre-ranked)\n\n", r.Identify.PromptDocs, r.Identify.ManifestDocs)
+		}
+		if r.Identify.Reason != "" {
+			fmt.Fprintf(&sb, "> %s\n", strings.ReplaceAll(r.Identify.Reason, "\n", "\n> "))
+		}
+	}
+
+	if len(r.Files) > 0 {
+		sb.WriteString("\n### Files\n\n")
+		sb.WriteString("| File | Update type | Target | Outcome | Reason |\n")
+		sb.WriteString("|------|-------------|--------|---------|--------|\n")
+		for _, f := range r.Files {
+			fmt.Fprintf(&sb, "| `%s` | %s | %s | %s | %s |\n",
+				f.Path, f.UpdateType, escapeCell(f.TargetLocation), f.Outcome, f.Reason)
+		}
+
+		for _, f := range r.Files {
+			if f.Error == "" && len(f.Warnings) == 0 {
+				continue
+			}
+			fmt.Fprintf(&sb, "\n#### `%s`\n\n", f.Path)
+			if f.Error != "" {
+				fmt.Fprintf(&sb, "- Error: %s\n", f.Error)
+			}
+			for _, w := range f.Warnings {
+				fmt.Fprintf(&sb, "- Warning: %s\n", w)
+			}
+		}
+	}
+
+	if len(r.Counts.ByReason) > 0 {
+		sb.WriteString("\n### Skip Reasons\n\n")
+		for _, reason := range slices.Sorted(maps.Keys(r.Counts.ByReason)) {
+			fmt.Fprintf(&sb, "- %s: %d\n", reason, r.Counts.ByReason[reason])
+		}
+	}
+
+	if len(r.Tokens) > 0 {
+		sb.WriteString("\n### Token Estimates\n\n")
+		if r.Tokenizer != "" {
+			fmt.Fprintf(&sb, "Tokenizer: %s\n\n", r.Tokenizer)
+		}
+		for _, k := range slices.Sorted(maps.Keys(r.Tokens)) {
+			fmt.Fprintf(&sb, "- %s: ~%d\n", k, r.Tokens[k])
+		}
+	}
+
+	if r.Usage != nil {
+		sb.WriteString("\n### Usage\n\n")
+		fmt.Fprintf(&sb, "%d requests, %d prompt tokens, %d completion tokens\n",
+			r.Usage.Requests, r.Usage.PromptTokens, r.Usage.CompletionTokens)
+	}
+
+	if len(r.Phases) > 0 {
+		sb.WriteString("\n### Phases\n\n")
+		sb.WriteString("| Phase | Status | Duration | Detail |\n")
+		sb.WriteString("|-------|--------|----------|--------|\n")
+		for _, p := range r.Phases {
+			fmt.Fprintf(&sb, "| %s | %s | %dms | %s |\n", p.Name, p.Status, p.DurationMS, escapeCell(p.Detail))
+		}
+	}
+
+	return sb.String()
+}
+
+// escapeCell makes a string safe for a single Markdown table cell.
+func escapeCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
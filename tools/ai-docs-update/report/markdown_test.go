@@ -0,0 +1,76 @@
+package report
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "Rewrite testdata/*.golden with the current output")
+
+func TestMarkdown(t *testing.T) {
+	r := New()
+	r.now = fixedClock(250 * time.Millisecond)
+	r.DryRun = true
+	r.Provider, r.Model = "anthropic", "claude-test"
+	r.Reason = ReasonTokenLimitExceeded
+	r.FilteredPaths = []string{"go.sum", "proto/feature/service.pb.go"}
+	r.Redactions = map[string]int{"password": 1, "github_token": 2}
+	r.Tokenizer = "o200k_base"
+	r.SetTokens("phase2:feature-flags/segments.mdx", 5200)
+	r.SetTokens("phase1", 12000)
+	r.Usage = &Usage{Requests: 3, PromptTokens: 18000, CompletionTokens: 2400}
+	r.Identify = &IdentifyResult{
+		NeedsUpdate:  true,
+		Reason:       "Segments gained a country rule.\nThe SDK docs already cover it.",
+		ManifestDocs: 65,
+		PromptDocs:   40,
+	}
+
+	p := r.StartPhase("load_context")
+	p.End(StatusCompleted, "12 glossary entries | 3 style guide rules")
+	p = r.StartPhase("identify")
+	p.End(StatusCompleted, "2 files\nselected")
+
+	updated := r.AddFile("feature-flags/segments.mdx", "add_inline", "Mention countries", "In '## Rules | Conditions' section,\nafter the list")
+	updated.Outcome = OutcomeDiffed
+	updated.Warnings = []string{"heading levels changed"}
+	hub := r.AddFile("feature-flags/index.mdx", "modify_section", "", "## Overview")
+	hub.Skip(ReasonHubPage, errors.New("feature-flags/index.mdx is a hub page"))
+	large := r.AddFile("sdk/android.mdx", "add_section", "", "")
+	large.Skip(ReasonDocTooLarge, nil)
+
+	r.Finish(errors.New("phase 2: token limit exceeded"))
+
+	got := r.Markdown()
+	golden := filepath.Join("testdata", "report.md.golden")
+	if *updateGolden {
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("Markdown() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEscapeCell(t *testing.T) {
+	tests := map[string]string{
+		"plain":              "plain",
+		"a | b":              `a \| b`,
+		"line one\nline two": "line one line two",
+		"x|y\nz":             `x\|y z`,
+	}
+	for in, want := range tests {
+		if got := escapeCell(in); got != want {
+			t.Errorf("escapeCell(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
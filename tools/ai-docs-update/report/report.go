@@ -0,0 +1,244 @@
+// Package report records the decisions made during a run in a machine-readable form.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// SchemaVersion is incremented when the JSON layout changes incompatibly.
+const SchemaVersion = 1
+
+// Status describes how a run or phase ended.
+type Status string
+
+const (
+	// StatusCompleted means the run or phase finished normally.
+	StatusCompleted Status = "completed"
+	// StatusSkipped means a guardrail stopped the run or phase (expected behavior).
+	StatusSkipped Status = "skipped"
+	// StatusNoUpdate means the AI decided that no docs need updating.
+	StatusNoUpdate Status = "no_update"
+	// StatusFailed means the run or phase ended with an error.
+	StatusFailed Status = "failed"
+)
+
+// Outcome describes what happened to a single candidate file.
+type Outcome string
+
+const (
+	// OutcomeUpdated means the file was written.
+	OutcomeUpdated Outcome = "updated"
+	// OutcomeDiffed means a dry-run diff was produced instead of writing.
+	OutcomeDiffed Outcome = "diffed"
+	// OutcomeSkipped means a guardrail or error prevented the update.
+	OutcomeSkipped Outcome = "skipped"
+)
+
+// Reason is a stable, machine-readable skip reason.
+type Reason string
+
+// Skip reasons for candidate files and runs.
+const (
+	ReasonReadFailed          Reason = "read_failed"
+	ReasonDocTooLarge         Reason = "doc_too_large"
+	ReasonTokenLimitExceeded  Reason = "token_limit_exceeded"
+	ReasonLLMError            Reason = "llm_error"
+	ReasonMissingDocumentTags Reason = "missing_document_tags"
+	ReasonOutputTooLarge      Reason = "output_too_large"
+	ReasonInvalidMarkdown     Reason = "invalid_markdown"
+	ReasonEmptyContent        Reason = "empty_content"
+	ReasonPathNotInManifest   Reason = "path_not_in_manifest"
+	ReasonHubPage             Reason = "hub_page"
+	ReasonTargetNotFound      Reason = "target_not_found"
+	ReasonPlatformMismatch    Reason = "platform_mismatch"
+	ReasonStructureChanged    Reason = "structure_changed"
+	ReasonWriteFailed         Reason = "write_failed"
+	ReasonInputGuardrails     Reason = "input_guardrails"
+	ReasonDiffGuardrails      Reason = "diff_guardrails"
+	ReasonSecretsDetected     Reason = "secrets_detected"
+	ReasonProviderConfig      Reason = "provider_config"
+	ReasonOther               Reason = "other"
+)
+
+// Report is the full record of a single invocation.
+type Report struct {
+	SchemaVersion int             `json:"schema_version"`
+	StartedAt     time.Time       `json:"started_at"`
+	FinishedAt    time.Time       `json:"finished_at"`
+	DryRun        bool            `json:"dry_run"`
+	Provider      string          `json:"provider,omitempty"`
+	Model         string          `json:"model,omitempty"`
+	IssueTitle    string          `json:"issue_title,omitempty"`
+	PRTitle       string          `json:"pr_title,omitempty"`
+	Status        Status          `json:"status"`
+	Reason        Reason          `json:"reason,omitempty"`
+	Error         string          `json:"error,omitempty"`
+	Phases        []*Phase        `json:"phases"`
+	FilteredPaths []string        `json:"filtered_paths,omitempty"` // Changed files left out of the diff by the path filters
+	Redactions    map[string]int  `json:"redactions,omitempty"`     // Secrets redacted from the issue and PR context, by kind
+	Identify      *IdentifyResult `json:"identify,omitempty"`
+	Files         []*FileResult   `json:"files"`
+	Counts        Counts          `json:"counts"`
+	Tokens        map[string]int  `json:"token_estimates,omitempty"`
+	Tokenizer     string          `json:"tokenizer,omitempty"` // BPE encoding the estimates were counted with, or "heuristic"
+	Usage         *Usage          `json:"usage,omitempty"`
+	now           func() time.Time
+}
+
+// Phase records the timing and result of one pipeline phase.
+type Phase struct {
+	Name       string    `json:"name"`
+	Status     Status    `json:"status"`
+	Detail     string    `json:"detail,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMS int64     `json:"duration_ms"`
+	report     *Report
+}
+
+// IdentifyResult records the Phase 1 response.
+type IdentifyResult struct {
+	NeedsUpdate  bool     `json:"needs_update"`
+	Reason       string   `json:"reason"`
+	ManifestDocs int      `json:"manifest_docs"`       // Docs in the manifest
+	PromptDocs   int      `json:"prompt_docs"`         // Docs sent to Phase 1 after routing and pre-ranking
+	Platforms    []string `json:"platforms,omitempty"` // SDK platforms the change was routed to
+}
+
+// FileResult records the outcome for one candidate file.
+type FileResult struct {
+	Path             string   `json:"path"`
+	UpdateType       string   `json:"update_type"`
+	BriefDescription string   `json:"brief_description,omitempty"`
+	TargetLocation   string   `json:"target_location,omitempty"`
+	Outcome          Outcome  `json:"outcome"`
+	Reason           Reason   `json:"reason,omitempty"`
+	Error            string   `json:"error,omitempty"`
+	Warnings         []string `json:"warnings,omitempty"`
+}
+
+// Usage holds the token usage reported by the LLM provider.
+type Usage struct {
+	Requests         int `json:"requests"`
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// Counts holds aggregate file counts.
+type Counts struct {
+	Candidates int            `json:"candidates"`
+	Updated    int            `json:"updated"`
+	Diffed     int            `json:"diffed"`
+	Skipped    int            `json:"skipped"`
+	ByReason   map[Reason]int `json:"by_reason,omitempty"`
+}
+
+// New creates an empty report stamped with the current time.
+func New() *Report {
+	r := &Report{
+		SchemaVersion: SchemaVersion,
+		Status:        StatusCompleted,
+		Phases:        []*Phase{},
+		Files:         []*FileResult{},
+		now:           time.Now,
+	}
+	r.StartedAt = r.now()
+	return r
+}
+
+// StartPhase begins timing a named phase. Call End on the result.
+func (r *Report) StartPhase(name string) *Phase {
+	p := &Phase{
+		Name:      name,
+		Status:    StatusCompleted,
+		StartedAt: r.now(),
+		report:    r,
+	}
+	r.Phases = append(r.Phases, p)
+	return p
+}
+
+// End records the phase status and duration.
+func (p *Phase) End(status Status, detail string) {
+	p.Status = status
+	p.Detail = detail
+	p.DurationMS = p.report.now().Sub(p.StartedAt).Milliseconds()
+}
+
+// AddFile registers a candidate file. The outcome is filled in later.
+func (r *Report) AddFile(path, updateType, briefDescription, targetLocation string) *FileResult {
+	f := &FileResult{
+		Path:             path,
+		UpdateType:       updateType,
+		BriefDescription: briefDescription,
+		TargetLocation:   targetLocation,
+	}
+	r.Files = append(r.Files, f)
+	return f
+}
+
+// Skip marks the file as skipped with a reason and the underlying error.
+func (f *FileResult) Skip(reason Reason, err error) {
+	f.Outcome = OutcomeSkipped
+	f.Reason = reason
+	if err != nil {
+		f.Error = err.Error()
+	}
+}
+
+// SetTokens records a token estimate under the given key (e.g. "phase1").
+func (r *Report) SetTokens(key string, tokens int) {
+	if r.Tokens == nil {
+		r.Tokens = make(map[string]int)
+	}
+	r.Tokens[key] = tokens
+}
+
+// Finish stamps the end time, records the final status and computes counts.
+// A non-nil err marks the run as failed.
+func (r *Report) Finish(err error) {
+	r.FinishedAt = r.now()
+	if err != nil {
+		r.Status = StatusFailed
+		r.Error = err.Error()
+	}
+
+	counts := Counts{Candidates: len(r.Files)}
+	for _, f := range r.Files {
+		switch f.Outcome {
+		case OutcomeUpdated:
+			counts.Updated++
+		case OutcomeDiffed:
+			counts.Diffed++
+		case OutcomeSkipped:
+			counts.Skipped++
+			if counts.ByReason == nil {
+				counts.ByReason = make(map[Reason]int)
+			}
+			counts.ByReason[f.Reason]++
+		}
+	}
+	r.Counts = counts
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// WriteMarkdown writes the report as Markdown suitable for a PR body.
+func (r *Report) WriteMarkdown(path string) error {
+	if err := os.WriteFile(path, []byte(r.Markdown()), 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package report
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fixedClock returns a clock that advances by step on every call.
+func fixedClock(step time.Duration) func() time.Time {
+	t := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		t = t.Add(step)
+		return t
+	}
+}
+
+func TestFinish(t *testing.T) {
+	tests := []struct {
+		name         string
+		outcomes     []Outcome
+		reasons      []Reason
+		err          error
+		wantStatus   Status
+		wantCounts   Counts
+		wantErrorMsg string
+	}{
+		{
+			name:       "no files",
+			wantStatus: StatusCompleted,
+			wantCounts: Counts{},
+		},
+		{
+			name:       "mixed outcomes",
+			outcomes:   []Outcome{OutcomeUpdated, OutcomeSkipped, OutcomeDiffed, OutcomeSkipped, OutcomeSkipped, OutcomeUpdated},
+			reasons:    []Reason{"", ReasonHubPage, "", ReasonLLMError, ReasonHubPage, ""},
+			wantStatus: StatusCompleted,
+			wantCounts: Counts{
+				Candidates: 6, Updated: 2, Diffed: 1, Skipped: 3,
+				ByReason: map[Reason]int{ReasonHubPage: 2, ReasonLLMError: 1},
+			},
+		},
+		{
+			name:       "pending files are candidates only",
+			outcomes:   []Outcome{"", OutcomeUpdated},
+			reasons:    []Reason{"", ""},
+			wantStatus: StatusCompleted,
+			wantCounts: Counts{Candidates: 2, Updated: 1},
+		},
+		{
+			name:         "failed run",
+			outcomes:     []Outcome{OutcomeSkipped},
+			reasons:      []Reason{ReasonWriteFailed},
+			err:          errors.New("context deadline exceeded"),
+			wantStatus:   StatusFailed,
+			wantCounts:   Counts{Candidates: 1, Skipped: 1, ByReason: map[Reason]int{ReasonWriteFailed: 1}},
+			wantErrorMsg: "context deadline exceeded",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			r.now = fixedClock(time.Second)
+			for i, outcome := range tt.outcomes {
+				f := r.AddFile("doc.mdx", "add_inline", "", "")
+				f.Outcome, f.Reason = outcome, tt.reasons[i]
+			}
+			r.Finish(tt.err)
+
+			if r.Status != tt.wantStatus || r.Error != tt.wantErrorMsg {
+				t.Errorf("Status, Error = %s, %q; want %s, %q", r.Status, r.Error, tt.wantStatus, tt.wantErrorMsg)
+			}
+			if !reflect.DeepEqual(r.Counts, tt.wantCounts) {
+				t.Errorf("Counts = %+v, want %+v", r.Counts, tt.wantCounts)
+			}
+			if r.FinishedAt.IsZero() {
+				t.Errorf("FinishedAt is not set")
+			}
+		})
+	}
+}
+
+func TestPhaseEnd(t *testing.T) {
+	r := New()
+	r.now = fixedClock(1500 * time.Millisecond)
+	p := r.StartPhase("identify")
+	p.End(StatusSkipped, "token limit")
+
+	if p.Status != StatusSkipped || p.Detail != "token limit" || p.DurationMS != 1500 {
+		t.Errorf("phase = %+v, want skipped after 1500ms", p)
+	}
+	if len(r.Phases) != 1 || r.Phases[0] != p {
+		t.Errorf("Phases = %v, want the started phase", r.Phases)
+	}
+}
@@ -0,0 +1,198 @@
+// Package retrieval ranks documentation files against issue and PR text
+// with an offline BM25 index, so Phase 1 only sees the likely candidates.
+package retrieval
+
+import (
+	"math"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/docs"
+)
+
+// BM25 parameters (the usual defaults).
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Field weights: a term in a title counts as much as titleWeight body occurrences.
+const (
+	titleWeight       = 4
+	pathWeight        = 3
+	headingWeight     = 2
+	descriptionWeight = 2
+	bodyWeight        = 1
+)
+
+// Index is a BM25 index over manifest docs.
+type Index struct {
+	docs   []indexedDoc
+	df     map[string]int // Number of docs containing each term
+	avgLen float64
+}
+
+type indexedDoc struct {
+	path   string
+	tf     map[string]float64 // Weighted term frequency
+	length float64            // Weighted number of terms
+}
+
+// Result is a ranked doc.
+type Result struct {
+	Path  string
+	Score float64
+}
+
+// NewIndex indexes the manifest docs' titles, paths, headings, descriptions
+// and body text. Bodies are read from docsDir; a doc that cannot be read is
+// indexed without its body.
+//
+// Term frequencies are not kept in the manifest cache: GenerateManifest reads
+// every doc to hash it anyway, indexing the docs tree takes tens of
+// milliseconds once per run, and caching them would tie the cache format
+// (and cacheVersion) to this package's tokenizer.
+func NewIndex(m *docs.Manifest, docsDir string) *Index {
+	idx := &Index{df: make(map[string]int)}
+	var totalLen float64
+	for _, f := range m.Files {
+		d := indexedDoc{path: f.Path, tf: make(map[string]float64)}
+		add := func(text string, weight float64) {
+			for _, term := range Tokenize(text) {
+				d.tf[term] += weight
+				d.length += weight
+			}
+		}
+
+		add(f.Title, titleWeight)
+		add(f.Path, pathWeight)
+		for _, h := range f.Outline {
+			add(h.Text, headingWeight)
+		}
+		add(f.Description, descriptionWeight)
+		if body, err := docs.ReadFile(filepath.Join(docsDir, f.Path)); err == nil {
+			add(body, bodyWeight)
+		}
+
+		for term := range d.tf {
+			idx.df[term]++
+		}
+		totalLen += d.length
+		idx.docs = append(idx.docs, d)
+	}
+	if len(idx.docs) > 0 {
+		idx.avgLen = totalLen / float64(len(idx.docs))
+	}
+	return idx
+}
+
+// Rank scores every indexed doc against the query, highest first.
+// Docs with the same score keep manifest order.
+func (idx *Index) Rank(query string) []Result {
+	terms := Tokenize(query)
+	queryTF := make(map[string]int)
+	for _, t := range terms {
+		queryTF[t]++
+	}
+
+	n := float64(len(idx.docs))
+	results := make([]Result, len(idx.docs))
+	for i, d := range idx.docs {
+		var score float64
+		for term, qtf := range queryTF {
+			tf := d.tf[term]
+			if tf == 0 {
+				continue
+			}
+			df := float64(idx.df[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := tf * (k1 + 1) / (tf + k1*(1-b+b*d.length/idx.avgLen))
+			// Repeated query terms count, with diminishing returns
+			score += idf * norm * (1 + math.Log(float64(qtf)))
+		}
+		results[i] = Result{Path: d.path, Score: score}
+	}
+
+	slices.SortStableFunc(results, func(a, b Result) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+	return results
+}
+
+// Tokenize splits text into lowercased, crudely stemmed terms. Identifiers
+// are split at case changes and punctuation ("featureFlag_id" gives
+// "feature", "flag", "id"); stop words and single characters are dropped.
+func Tokenize(text string) []string {
+	var terms []string
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		for _, part := range splitCamelCase(word) {
+			term := stem(strings.ToLower(part))
+			if len(term) < 2 || stopWords[term] {
+				continue
+			}
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// splitCamelCase splits "featureFlagID" into "feature", "Flag", "ID".
+func splitCamelCase(word string) []string {
+	runes := []rune(word)
+	var parts []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		lowerToUpper := unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i])
+		acronymEnd := i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i+1])
+		if lowerToUpper || acronymEnd {
+			parts = append(parts, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(parts, string(runes[start:]))
+}
+
+// stem strips common English suffixes so "flags", "flagged" and "flagging"
+// match. It is deliberately crude; it only needs to be consistent.
+func stem(term string) string {
+	for _, suffix := range []string{"ing", "ed", "es", "s"} {
+		s := strings.TrimSuffix(term, suffix)
+		if s == term || len(s) < 3 {
+			continue
+		}
+		// "flagg" (from "flagged") becomes "flag"; "roll" and "pass" stay
+		if n := len(s); (suffix == "ing" || suffix == "ed") && n >= 4 && s[n-1] == s[n-2] && !strings.ContainsRune("aeioulsz", rune(s[n-1])) {
+			s = s[:n-1]
+		}
+		return s
+	}
+	return term
+}
+
+// stopWords are frequent words that carry no topic (after stemming).
+var stopWords = toSet(
+	"the", "and", "for", "are", "but", "not", "you", "all", "can", "her", "was", "one", "our", "out",
+	"use", "an", "as", "at", "be", "by", "do", "if", "in", "is", "it", "of", "on", "or", "so", "to",
+	"up", "we", "this", "that", "with", "from", "they", "will", "would", "there", "their", "what",
+	"when", "which", "your", "have", "has", "had", "been", "more", "into", "than", "then", "them",
+	"these", "those", "also", "only", "other", "some", "such", "should", "could", "about", "after",
+	"before", "how", "its", "may", "must", "new", "now", "each", "any", "via", "per", "see",
+)
+
+func toSet(items ...string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
@@ -0,0 +1,141 @@
+package retrieval
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/docs"
+)
+
+// newTestIndex writes the doc bodies to a temp docs directory and indexes m.
+func newTestIndex(t *testing.T, m *docs.Manifest, bodies map[string]string) *Index {
+	t.Helper()
+	docsDir := t.TempDir()
+	for path, body := range bodies {
+		full := filepath.Join(docsDir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewIndex(m, docsDir)
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "featureFlag_id", want: []string{"feature", "flag", "id"}},
+		{text: "The flags are flagged when flagging", want: []string{"flag", "flag", "flag"}},
+		{text: "Segments: user-segment", want: []string{"segment", "user", "segment"}},
+		{text: "HTTPServer rolled passed targeting", want: []string{"http", "server", "roll", "pass", "target"}},
+		{text: "a I x y2 is to", want: []string{"y2"}},
+		{text: "sdk/android/index.mdx", want: []string{"sdk", "android", "index", "mdx"}},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSplitCamelCase(t *testing.T) {
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{word: "featureFlagID", want: []string{"feature", "Flag", "ID"}},
+		{word: "HTTPServer", want: []string{"HTTP", "Server"}},
+		{word: "getAPIKey", want: []string{"get", "API", "Key"}},
+		{word: "segment", want: []string{"segment"}},
+		{word: "ID", want: []string{"ID"}},
+	}
+	for _, tt := range tests {
+		if got := splitCamelCase(tt.word); !slices.Equal(got, tt.want) {
+			t.Errorf("splitCamelCase(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestStem(t *testing.T) {
+	tests := map[string]string{
+		"flags":     "flag",
+		"flagged":   "flag",
+		"flagging":  "flag",
+		"targeting": "target",
+		"rolled":    "roll",
+		"passes":    "pass",
+		"uses":      "use", // too short to strip "es"
+		"is":        "is",
+		"bus":       "bus",
+	}
+	for term, want := range tests {
+		if got := stem(term); got != want {
+			t.Errorf("stem(%q) = %q, want %q", term, got, want)
+		}
+	}
+}
+
+func TestNewIndexFieldWeights(t *testing.T) {
+	m := &docs.Manifest{Files: []docs.DocFile{{
+		Path:        "flags/rollout.mdx",
+		Title:       "Segment",
+		Description: "Segment",
+		Outline:     []docs.Heading{{Level: 2, Text: "Segment"}},
+	}}}
+	idx := newTestIndex(t, m, map[string]string{"flags/rollout.mdx": "segment segment"})
+
+	d := idx.docs[0]
+	if want := float64(titleWeight + descriptionWeight + headingWeight + 2*bodyWeight); d.tf["segment"] != want {
+		t.Errorf("tf[segment] = %v, want %v", d.tf["segment"], want)
+	}
+	if d.tf["rollout"] != pathWeight || d.tf["flag"] != pathWeight {
+		t.Errorf("tf[rollout], tf[flag] = %v, %v; want %v each", d.tf["rollout"], d.tf["flag"], pathWeight)
+	}
+	// "flags", "rollout" and "mdx" from the path
+	if want := float64(titleWeight + descriptionWeight + headingWeight + 2*bodyWeight + 3*pathWeight); d.length != want {
+		t.Errorf("length = %v, want %v", d.length, want)
+	}
+}
+
+func TestRank(t *testing.T) {
+	m := &docs.Manifest{Files: []docs.DocFile{
+		{Path: "feature-flags/audit-logs.mdx", Title: "Audit logs"},
+		{Path: "feature-flags/segments.mdx", Title: "Reusable rules"},
+		{Path: "getting-started/quickstart.mdx", Title: "Quickstart"},
+		{Path: "sdk/android.mdx", Title: "Android"},
+		{Path: "sdk/ios.mdx", Title: "iOS"},
+	}}
+	idx := newTestIndex(t, m, map[string]string{
+		"feature-flags/audit-logs.mdx":   "Every change to segments and flags is logged.",
+		"feature-flags/segments.mdx":     "Group users by attributes.",
+		"getting-started/quickstart.mdx": "Create a flag and evaluate it.",
+		"sdk/android.mdx":                "Install the SDK.",
+		"sdk/ios.mdx":                    "Install the SDK.",
+	})
+
+	results := idx.Rank("Add country to segments")
+	var paths []string
+	for _, r := range results {
+		paths = append(paths, r.Path)
+	}
+	// A path match outranks a body mention; unmatched docs keep manifest order
+	want := []string{"feature-flags/segments.mdx", "feature-flags/audit-logs.mdx", "getting-started/quickstart.mdx", "sdk/android.mdx", "sdk/ios.mdx"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("Rank() = %v, want %v", paths, want)
+	}
+	if results[1].Score <= 0 || results[2].Score != 0 {
+		t.Errorf("scores = %v, want a body mention above zero and no match at zero", results)
+	}
+
+	// Repeated query terms weigh more, with diminishing returns
+	once, twice, thrice := idx.Rank("segments")[0].Score, idx.Rank("segments segments")[0].Score, idx.Rank("segments segments segments")[0].Score
+	if !(once < twice && twice < thrice && thrice-twice < twice-once) {
+		t.Errorf("scores for 1, 2, 3 repeats = %v, %v, %v; want increasing with diminishing returns", once, twice, thrice)
+	}
+}
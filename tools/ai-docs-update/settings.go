@@ -0,0 +1,121 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/anthropic"
+	appconfig "github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/config"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/openai"
+)
+
+// registerSettingsFlags registers the flags that override configuration values
+// and returns the --config path flag.
+func registerSettingsFlags(fs *flag.FlagSet) *string {
+	defaults := appconfig.Default()
+	configFile := fs.String("config", "", "Path to configuration file (default: "+appconfig.DefaultFileName+" if present)")
+	fs.String("docs-dir", defaults.Docs.Dir, "Path to docs directory")
+	fs.String("exclude-dirs", "", "Comma-separated directories to exclude (default: changelog,contribution-guide)")
+	fs.String("exclude-files", "", "Comma-separated files to exclude (default: bucketeer-docs.mdx)")
+	fs.String("provider", "", "LLM provider: openai, anthropic (default: $LLM_PROVIDER or openai)")
+	fs.String("model", "", "LLM model (default: $OPENAI_MODEL / $ANTHROPIC_MODEL or the provider default)")
+	fs.Int("concurrency", defaults.Generation.Concurrency, "Maximum number of Phase 2 generations to run in parallel")
+	return configFile
+}
+
+// loadSettings merges defaults, the configuration file, environment variables
+// and explicitly set flags (in that order of precedence).
+func loadSettings(configFile string, fs *flag.FlagSet) (*appconfig.Config, error) {
+	settings, err := appconfig.Load(configFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := settings.ApplyEnv(); err != nil {
+		return nil, err
+	}
+
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		value := f.Value.String()
+		switch f.Name {
+		case "docs-dir":
+			settings.Docs.Dir = value
+		case "exclude-dirs":
+			if value != "" {
+				settings.Docs.ExcludeDirs = appconfig.SplitList(value)
+			}
+		case "exclude-files":
+			if value != "" {
+				settings.Docs.ExcludeFiles = appconfig.SplitList(value)
+			}
+		case "provider":
+			settings.LLM.Provider = value
+		case "model":
+			settings.LLM.Model = value
+		case "concurrency":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				flagErr = fmt.Errorf("invalid --concurrency: %w", err)
+			}
+			settings.Generation.Concurrency = n
+		}
+	})
+	if flagErr != nil {
+		return nil, flagErr
+	}
+
+	// Provider-specific model variables keep working as before, below the
+	// file's llm.model and AI_DOCS_MODEL
+	if settings.LLM.Model == "" {
+		settings.LLM.Model = os.Getenv(modelEnv(settings.LLM.Provider))
+	}
+	if settings.LLM.Model == "" {
+		settings.LLM.Model = defaultModel(settings.LLM.Provider)
+	}
+
+	return settings, settings.Validate()
+}
+
+// modelEnv returns the model environment variable for a provider.
+func modelEnv(provider string) string {
+	if provider == anthropic.ProviderName {
+		return anthropic.EnvAnthropicModel
+	}
+	return openai.EnvOpenAIModel
+}
+
+// defaultModel returns the default model for a provider.
+func defaultModel(provider string) string {
+	if provider == anthropic.ProviderName {
+		return anthropic.DefaultModel
+	}
+	return openai.DefaultModel
+}
+
+// runConfigCommand implements `config print`, which shows the effective
+// merged configuration.
+func runConfigCommand(args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return fmt.Errorf("usage: ai-docs-update config print [flags]")
+	}
+
+	fs := flag.NewFlagSet("config print", flag.ExitOnError)
+	configFile := registerSettingsFlags(fs)
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	settings, err := loadSettings(*configFile, fs)
+	if err != nil {
+		return err
+	}
+
+	out, err := settings.YAML()
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	return nil
+}
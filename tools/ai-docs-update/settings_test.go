@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/anthropic"
+	"github.com/bucketeer-io/bucketeer-docs/tools/ai-docs-update/openai"
+)
+
+func TestLoadSettingsModelPrecedence(t *testing.T) {
+	tests := []struct {
+		name      string
+		fileModel string
+		env       map[string]string
+		args      []string
+		want      string
+	}{
+		{
+			name: "provider default",
+			want: openai.DefaultModel,
+		},
+		{
+			name: "provider variable over the default",
+			env:  map[string]string{"OPENAI_MODEL": "openai-env"},
+			want: "openai-env",
+		},
+		{
+			name:      "file over the provider variable",
+			fileModel: "file-model",
+			env:       map[string]string{"OPENAI_MODEL": "openai-env"},
+			want:      "file-model",
+		},
+		{
+			name:      "AI_DOCS_MODEL over the file and the provider variable",
+			fileModel: "file-model",
+			env:       map[string]string{"AI_DOCS_MODEL": "env-model", "OPENAI_MODEL": "openai-env"},
+			want:      "env-model",
+		},
+		{
+			name:      "flag over everything",
+			fileModel: "file-model",
+			env:       map[string]string{"AI_DOCS_MODEL": "env-model", "OPENAI_MODEL": "openai-env"},
+			args:      []string{"--model", "flag-model"},
+			want:      "flag-model",
+		},
+		{
+			name: "variable of the provider chosen by flag",
+			env:  map[string]string{"OPENAI_MODEL": "openai-env", "ANTHROPIC_MODEL": "anthropic-env"},
+			args: []string{"--provider", anthropic.ProviderName},
+			want: "anthropic-env",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"AI_DOCS_MODEL", "LLM_PROVIDER", "OPENAI_MODEL", "ANTHROPIC_MODEL"} {
+				t.Setenv(name, tt.env[name])
+			}
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte("llm:\n  model: \""+tt.fileModel+"\"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			registerSettingsFlags(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			settings, err := loadSettings(path, fs)
+			if err != nil {
+				t.Fatalf("loadSettings() error = %v", err)
+			}
+			if settings.LLM.Model != tt.want {
+				t.Errorf("LLM.Model = %q, want %q", settings.LLM.Model, tt.want)
+			}
+		})
+	}
+}
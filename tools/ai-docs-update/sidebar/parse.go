@@ -0,0 +1,262 @@
+package sidebar
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrUnsupportedJS indicates sidebars.js uses JavaScript beyond plain object literals.
+var ErrUnsupportedJS = errors.New("unsupported JavaScript in sidebars file (export it as JSON instead)")
+
+// exportPattern finds where the sidebars object literal starts.
+var exportPattern = regexp.MustCompile(`(?:module\.exports|export\s+default|(?:const|let|var)\s+sidebars)\s*=?\s*`)
+
+// ParseJS parses the object literal exported by a sidebars.js file.
+// Only JSON-like literals are supported: objects, arrays, strings, numbers,
+// booleans and null, plus comments, unquoted keys and trailing commas.
+// The result uses the same Go types as encoding/json.
+func ParseJS(src string) (any, error) {
+	loc := exportPattern.FindStringIndex(src)
+	if loc == nil {
+		return nil, fmt.Errorf("%w: no sidebars export found", ErrUnsupportedJS)
+	}
+	p := &jsParser{src: src, pos: loc[1]}
+	p.skipSpace()
+	if p.peek() != '{' {
+		return nil, p.errorf("expected object literal")
+	}
+	return p.value()
+}
+
+// jsParser is a recursive descent parser for JavaScript object literals.
+type jsParser struct {
+	src string
+	pos int
+}
+
+func (p *jsParser) peek() byte {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// errorf returns an ErrUnsupportedJS error with the current line number.
+func (p *jsParser) errorf(format string, args ...any) error {
+	line := strings.Count(p.src[:min(p.pos, len(p.src))], "\n") + 1
+	return fmt.Errorf("%w: line %d: %s", ErrUnsupportedJS, line, fmt.Sprintf(format, args...))
+}
+
+// skipSpace skips whitespace and comments.
+func (p *jsParser) skipSpace() {
+	for p.pos < len(p.src) {
+		switch {
+		case unicode.IsSpace(rune(p.src[p.pos])):
+			p.pos++
+		case strings.HasPrefix(p.src[p.pos:], "//"):
+			if end := strings.IndexByte(p.src[p.pos:], '\n'); end >= 0 {
+				p.pos += end + 1
+			} else {
+				p.pos = len(p.src)
+			}
+		case strings.HasPrefix(p.src[p.pos:], "/*"):
+			if end := strings.Index(p.src[p.pos+2:], "*/"); end >= 0 {
+				p.pos += end + 4
+			} else {
+				p.pos = len(p.src)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// value parses any literal value.
+func (p *jsParser) value() (any, error) {
+	p.skipSpace()
+	switch c := p.peek(); {
+	case c == '{':
+		return p.object()
+	case c == '[':
+		return p.array()
+	case c == '\'' || c == '"' || c == '`':
+		return p.string()
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.number()
+	case isIdentStart(c):
+		start := p.pos
+		ident := p.ident()
+		switch ident {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null", "undefined":
+			return nil, nil
+		}
+		p.pos = start
+		return nil, p.errorf("unsupported expression %q", ident)
+	case c == 0:
+		return nil, p.errorf("unexpected end of file")
+	default:
+		return nil, p.errorf("unexpected character %q", c)
+	}
+}
+
+// object parses an object literal with quoted or unquoted keys.
+func (p *jsParser) object() (map[string]any, error) {
+	p.pos++ // '{'
+	obj := make(map[string]any)
+	for {
+		p.skipSpace()
+		if p.peek() == '}' {
+			p.pos++
+			return obj, nil
+		}
+
+		var key string
+		switch c := p.peek(); {
+		case c == '\'' || c == '"' || c == '`':
+			s, err := p.string()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		case isIdentStart(c) || (c >= '0' && c <= '9'):
+			key = p.ident()
+		default:
+			return nil, p.errorf("expected object key, found %q", c)
+		}
+
+		p.skipSpace()
+		if p.peek() != ':' {
+			return nil, p.errorf("expected ':' after key %q", key)
+		}
+		p.pos++
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		obj[key] = v
+
+		if err := p.separator('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// array parses an array literal.
+func (p *jsParser) array() ([]any, error) {
+	p.pos++ // '['
+	arr := []any{}
+	for {
+		p.skipSpace()
+		if p.peek() == ']' {
+			p.pos++
+			return arr, nil
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+
+		if err := p.separator(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// separator consumes a ',' or checks for the closing character.
+func (p *jsParser) separator(closing byte) error {
+	p.skipSpace()
+	switch p.peek() {
+	case ',':
+		p.pos++
+		return nil
+	case closing:
+		return nil
+	default:
+		return p.errorf("expected ',' or %q", closing)
+	}
+}
+
+// string parses a single-, double- or back-quoted string without interpolation.
+func (p *jsParser) string() (string, error) {
+	quote := p.src[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		case c == '\\' && p.pos+1 < len(p.src):
+			p.pos++
+			switch e := p.src[p.pos]; e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case 'u':
+				if p.pos+4 >= len(p.src) {
+					return "", p.errorf("invalid unicode escape")
+				}
+				r, err := strconv.ParseUint(p.src[p.pos+1:p.pos+5], 16, 32)
+				if err != nil {
+					return "", p.errorf("invalid unicode escape")
+				}
+				sb.WriteRune(rune(r))
+				p.pos += 4
+			default:
+				sb.WriteByte(e)
+			}
+			p.pos++
+		case quote == '`' && strings.HasPrefix(p.src[p.pos:], "${"):
+			return "", p.errorf("template literal interpolation")
+		case c == '\n' && quote != '`':
+			return "", p.errorf("unterminated string")
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// number parses a decimal number.
+func (p *jsParser) number() (float64, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.src) && strings.IndexByte("0123456789.eE+-", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+	n, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+	if err != nil {
+		p.pos = start
+		return 0, p.errorf("invalid number")
+	}
+	return n, nil
+}
+
+// ident parses an identifier (or a bare numeric key).
+func (p *jsParser) ident() string {
+	start := p.pos
+	for p.pos < len(p.src) && (isIdentStart(p.src[p.pos]) || (p.src[p.pos] >= '0' && p.src[p.pos] <= '9')) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,79 @@
+package sidebar
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseJS(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want any
+	}{
+		{
+			name: "module.exports with comments and trailing commas",
+			src: `// @ts-check
+/** @type {import('@docusaurus/plugin-content-docs').SidebarsConfig} */
+module.exports = {
+  docs: [
+    'intro', // first
+    /* block */ {type: "doc", id: "a", label: 'It\'s A',},
+  ],
+};`,
+			want: map[string]any{"docs": []any{"intro", map[string]any{"type": "doc", "id": "a", "label": "It's A"}}},
+		},
+		{
+			name: "const sidebars then export",
+			src:  "const sidebars = {\n  docs: [`b`],\n  'other-sidebar': [],\n};\nexport default sidebars;\n",
+			want: map[string]any{"docs": []any{"b"}, "other-sidebar": []any{}},
+		},
+		{
+			name: "export default literal",
+			src:  `export default {docs: {Guides: ["x"]}}`,
+			want: map[string]any{"docs": map[string]any{"Guides": []any{"x"}}},
+		},
+		{
+			name: "scalars and escapes",
+			src:  `module.exports = {n: -1.5, t: true, f: false, z: null, u: undefined, 1: "é\n"}`,
+			want: map[string]any{"n": -1.5, "t": true, "f": false, "z": nil, "u": nil, "1": "é\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseJS(tt.src)
+			if err != nil {
+				t.Fatalf("ParseJS() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseJS() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJSUnsupported(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantMsg string
+	}{
+		{name: "no export", src: `{docs: []}`, wantMsg: "no sidebars export found"},
+		{name: "function call", src: "module.exports = {\n  docs: require('./docs.json'),\n}", wantMsg: `line 2: unsupported expression "require"`},
+		{name: "spread", src: `module.exports = {...base}`, wantMsg: "expected object key"},
+		{name: "interpolation", src: "module.exports = {docs: [`${dir}/a`]}", wantMsg: "template literal interpolation"},
+		{name: "missing comma", src: `module.exports = {a: 1 b: 2}`, wantMsg: `expected ',' or '}'`},
+		{name: "unterminated", src: `module.exports = {docs: ['a`, wantMsg: "unterminated string"},
+		{name: "truncated", src: `module.exports = {docs: [`, wantMsg: "unexpected end of file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseJS(tt.src)
+			if !errors.Is(err, ErrUnsupportedJS) || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("ParseJS() error = %v, want %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+// Package sidebar reads Docusaurus sidebars (sidebars.js or a JSON export)
+// and flattens them into the position of each doc in the navigation tree.
+package sidebar
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Entry is the position of one doc in a sidebar.
+type Entry struct {
+	DocID    string   `json:"doc_id"`
+	Sidebar  string   `json:"sidebar"`            // sidebar name (e.g. "docs")
+	Group    string   `json:"group"`              // section title and category labels, e.g. "feature flags > Targeting"
+	Label    string   `json:"label,omitempty"`    // sidebar label, if set
+	Parent   string   `json:"parent,omitempty"`   // doc ID linked by the enclosing category
+	Children []string `json:"children,omitempty"` // doc IDs in the category this doc is linked from
+	Position int      `json:"position"`           // 1-based order within the sidebar
+}
+
+// Sidebars holds the flattened entries of all sidebars.
+type Sidebars struct {
+	entries map[string]*Entry
+}
+
+// tagPattern strips HTML tags from section titles.
+var tagPattern = regexp.MustCompile(`<[^>]+>`)
+
+// Load reads a sidebars file. Files ending in .json are read as JSON,
+// anything else is parsed as a sidebars.js object literal.
+func Load(path string) (*Sidebars, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sidebars: %w", err)
+	}
+
+	var root any
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if err := json.Unmarshal(data, &root); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	} else if root, err = ParseJS(string(data)); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return New(root)
+}
+
+// New builds Sidebars from a decoded sidebars config
+// (an object mapping sidebar names to item lists).
+func New(root any) (*Sidebars, error) {
+	sidebars, ok := root.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("sidebars config must be an object, got %T", root)
+	}
+
+	s := &Sidebars{entries: make(map[string]*Entry)}
+	names := make([]string, 0, len(sidebars))
+	for name := range sidebars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		w := &walker{sidebars: s, sidebar: name}
+		w.items(sidebars[name], nil, "")
+	}
+	return s, nil
+}
+
+// Lookup returns the entry for a doc ID.
+func (s *Sidebars) Lookup(docID string) (*Entry, bool) {
+	e, ok := s.entries[docID]
+	return e, ok
+}
+
+// Len returns the number of docs found in the sidebars.
+func (s *Sidebars) Len() int {
+	return len(s.entries)
+}
+
+// walker flattens one sidebar in depth-first order.
+type walker struct {
+	sidebars *Sidebars
+	sidebar  string
+	section  string // current html section title
+	position int
+}
+
+// items walks a list of items (or a shorthand {"Label": [...]} object) under
+// the given category labels. parent is the doc ID linked by the enclosing category.
+// It returns the doc IDs found directly at this level.
+func (w *walker) items(v any, labels []string, parent string) []string {
+	var ids []string
+	switch items := v.(type) {
+	case []any:
+		for _, item := range items {
+			ids = append(ids, w.item(item, labels, parent)...)
+		}
+	case map[string]any:
+		// Shorthand: each key is a category label
+		keys := make([]string, 0, len(items))
+		for k := range items {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, label := range keys {
+			ids = append(ids, w.category(label, nil, items[label], labels, parent)...)
+		}
+	}
+	return ids
+}
+
+// item walks a single sidebar item and returns the doc IDs it adds at this level.
+func (w *walker) item(v any, labels []string, parent string) []string {
+	switch item := v.(type) {
+	case string:
+		return w.doc(item, "", labels, parent)
+	case map[string]any:
+		switch stringField(item, "type") {
+		case "doc":
+			return w.doc(stringField(item, "id"), stringField(item, "label"), labels, parent)
+		case "html":
+			if len(labels) == 0 {
+				w.section = strings.TrimSpace(tagPattern.ReplaceAllString(stringField(item, "value"), ""))
+			}
+		case "category":
+			return w.category(stringField(item, "label"), item["link"], item["items"], labels, parent)
+		case "":
+			// Shorthand category object nested in a list
+			return w.items(item, labels, parent)
+		}
+		// "link", "ref" and "autogenerated" items do not place docs
+	}
+	return nil
+}
+
+// category walks a category. A linked doc becomes the parent of the category's items.
+func (w *walker) category(label string, link, items any, labels []string, parent string) []string {
+	labels = append(append([]string{}, labels...), label)
+
+	var linkID string
+	if l, ok := link.(map[string]any); ok && stringField(l, "type") == "doc" {
+		linkID = stringField(l, "id")
+		w.doc(linkID, label, labels[:len(labels)-1], parent)
+	}
+
+	childParent := parent
+	if linkID != "" {
+		childParent = linkID
+	}
+	children := w.items(items, labels, childParent)
+
+	if linkID == "" {
+		// Without a linked doc the category is transparent for parent/child links
+		return children
+	}
+	if e, ok := w.sidebars.entries[linkID]; ok && e.Sidebar == w.sidebar {
+		e.Children = append(e.Children, children...)
+	}
+	return []string{linkID}
+}
+
+// doc records a doc entry. Docs already placed (e.g. in another sidebar) keep their first position.
+func (w *walker) doc(id, label string, labels []string, parent string) []string {
+	if id == "" {
+		return nil
+	}
+	if _, exists := w.sidebars.entries[id]; exists {
+		return []string{id}
+	}
+	w.position++
+	group := w.section
+	if len(labels) > 0 {
+		group = strings.Join(append([]string{w.section}, labels...), " > ")
+		group = strings.TrimPrefix(group, " > ")
+	}
+	w.sidebars.entries[id] = &Entry{
+		DocID:    id,
+		Sidebar:  w.sidebar,
+		Group:    group,
+		Label:    label,
+		Parent:   parent,
+		Position: w.position,
+	}
+	return []string{id}
+}
+
+// stringField returns a string field of an object, or "".
+func stringField(obj map[string]any, key string) string {
+	s, _ := obj[key].(string)
+	return s
+}
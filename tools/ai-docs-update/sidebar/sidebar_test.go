@@ -0,0 +1,101 @@
+package sidebar
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testSidebars = `module.exports = {
+  docs: [
+    {type: 'doc', id: 'intro', label: 'Introduction'},
+    {type: 'html', value: "<span class='sidebar-title'>Feature flags</span>"},
+    {
+      type: 'category',
+      label: 'Targeting',
+      link: {type: 'doc', id: 'flags/targeting/index'},
+      items: [
+        'flags/targeting/rules',
+        {
+          type: 'category',
+          label: 'Advanced',
+          items: ['flags/targeting/prerequisites', {type: 'link', label: 'API', href: '/api'}],
+        },
+      ],
+    },
+    {type: 'html', value: '<b>SDKs</b>'},
+    {Client: ['sdk/android', 'sdk/ios']},
+  ],
+  api: ['intro', 'api/overview', {type: 'autogenerated', dirName: 'api'}],
+};
+`
+
+func TestNew(t *testing.T) {
+	root, err := ParseJS(testSidebars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(root)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// Sidebars are walked by name, so "intro" is placed in "api" and skipped in "docs"
+	want := []Entry{
+		{DocID: "api/overview", Sidebar: "api", Position: 2},
+		{DocID: "intro", Sidebar: "api", Position: 1},
+		{DocID: "flags/targeting/index", Sidebar: "docs", Group: "Feature flags", Label: "Targeting",
+			Children: []string{"flags/targeting/rules", "flags/targeting/prerequisites"}, Position: 1},
+		{DocID: "flags/targeting/rules", Sidebar: "docs", Group: "Feature flags > Targeting", Parent: "flags/targeting/index", Position: 2},
+		{DocID: "flags/targeting/prerequisites", Sidebar: "docs", Group: "Feature flags > Targeting > Advanced", Parent: "flags/targeting/index", Position: 3},
+		{DocID: "sdk/android", Sidebar: "docs", Group: "SDKs > Client", Position: 4},
+		{DocID: "sdk/ios", Sidebar: "docs", Group: "SDKs > Client", Position: 5},
+	}
+	if s.Len() != len(want) {
+		t.Errorf("Len() = %d, want %d", s.Len(), len(want))
+	}
+	for _, w := range want {
+		got, ok := s.Lookup(w.DocID)
+		if !ok {
+			t.Errorf("Lookup(%q) not found", w.DocID)
+			continue
+		}
+		if !reflect.DeepEqual(*got, w) {
+			t.Errorf("Lookup(%q) =\n%+v\nwant\n%+v", w.DocID, *got, w)
+		}
+	}
+	if _, ok := s.Lookup("api"); ok {
+		t.Error("Lookup(\"api\") found an autogenerated item")
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	if _, err := New([]any{"docs"}); err == nil {
+		t.Error("New() of a list: error = nil, want an error")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "sidebars.json")
+	if err := os.WriteFile(jsonPath, []byte(`{"docs": ["a", {"type": "doc", "id": "b"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(jsonPath)
+	if err != nil {
+		t.Fatalf("Load(JSON) error = %v", err)
+	}
+	if e, ok := s.Lookup("b"); !ok || e.Position != 2 {
+		t.Errorf("Lookup(\"b\") = %+v, %v; want position 2", e, ok)
+	}
+
+	// The site's own sidebars.js must stay parseable
+	s, err = Load("../../../sidebars.js")
+	if err != nil {
+		t.Fatalf("Load(sidebars.js) error = %v", err)
+	}
+	if _, ok := s.Lookup("getting-started/introduction"); !ok {
+		t.Error("sidebars.js: getting-started/introduction not found")
+	}
+}
@@ -0,0 +1,76 @@
+package tokenizer
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Estimate approximates the token count of text without a vocabulary. It
+// splits text the way the BPE pre-tokenizers do and charges each piece:
+//   - ASCII words: one token, plus one per 8 letters (long identifiers split)
+//   - numbers: one token per 3 digits
+//   - punctuation and symbols (code, JSON): one token per 2 characters
+//   - CJK characters: one token each
+//   - other letters (Cyrillic, accented Latin runs, ...): one token per 2
+//   - whitespace: free before a word, one token per newline run or longer space run
+func Estimate(text string) int {
+	tokens := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		start := i
+		switch {
+		case isCJK(r):
+			tokens++
+			i += size
+		case r < utf8.RuneSelf && (unicode.IsLetter(r) || r == '_'):
+			i = scan(text, i, func(r rune) bool { return r < utf8.RuneSelf && (unicode.IsLetter(r) || r == '_') })
+			tokens += 1 + (i-start)/8
+		case unicode.IsDigit(r):
+			i = scan(text, i, unicode.IsDigit)
+			tokens += (i - start + 2) / 3
+		case unicode.IsLetter(r) || unicode.IsMark(r):
+			n := 0
+			i = scan(text, i, func(r rune) bool {
+				ok := (unicode.IsLetter(r) || unicode.IsMark(r)) && r >= utf8.RuneSelf && !isCJK(r)
+				if ok {
+					n++
+				}
+				return ok
+			})
+			tokens += (n + 1) / 2
+		case r == '\n' || r == '\r':
+			i = scan(text, i, func(r rune) bool { return r == '\n' || r == '\r' })
+			tokens++
+		case unicode.IsSpace(r):
+			i = scan(text, i, func(r rune) bool { return unicode.IsSpace(r) && r != '\n' && r != '\r' })
+			if i-start > 1 || i == len(text) {
+				tokens++ // indentation; a single space joins the next word
+			}
+		default:
+			i = scan(text, i, func(r rune) bool {
+				return !unicode.IsSpace(r) && !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r)
+			})
+			tokens += (utf8.RuneCountInString(text[start:i]) + 1) / 2
+		}
+	}
+	return tokens
+}
+
+// scan returns the index after the run of runes matching match that starts at i.
+func scan(text string, i int, match func(rune) bool) int {
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !match(r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// isCJK reports whether r is a Han, kana or Hangul character, which BPE
+// vocabularies mostly encode as one or more tokens each.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		r == 'ー' || (r >= 0x3000 && r <= 0x303F) || (r >= 0xFF00 && r <= 0xFFEF)
+}
@@ -0,0 +1,57 @@
+package tokenizer
+
+import "strings"
+
+// modelInfo is what counting and budgeting need to know about a model family.
+type modelInfo struct {
+	prefix        string
+	encoding      string // empty if the vocabulary is not public
+	contextWindow int    // input tokens the model accepts
+}
+
+// models are matched by name prefix in order, so more specific prefixes come first.
+var models = []modelInfo{
+	{"gpt-5", EncodingO200K, 272000},
+	{"gpt-4.1", EncodingO200K, 1047576},
+	{"gpt-4.5", EncodingO200K, 128000},
+	{"gpt-4o", EncodingO200K, 128000},
+	{"chatgpt-4o", EncodingO200K, 128000},
+	{"o1-mini", EncodingO200K, 128000},
+	{"o1", EncodingO200K, 200000},
+	{"o3", EncodingO200K, 200000},
+	{"o4", EncodingO200K, 200000},
+	{"gpt-4-turbo", EncodingCL100K, 128000},
+	{"gpt-4-32k", EncodingCL100K, 32768},
+	{"gpt-4", EncodingCL100K, 8192},
+	{"gpt-3.5-turbo", EncodingCL100K, 16385},
+	{"claude", "", 200000},
+}
+
+// Room left in the context window for the response and estimate errors.
+const (
+	maxOutputReserve = 16384
+	budgetMargin     = 0.9
+)
+
+func lookupModel(model string) (modelInfo, bool) {
+	model = strings.ToLower(model)
+	for _, m := range models {
+		if strings.HasPrefix(model, m.prefix) {
+			return m, true
+		}
+	}
+	return modelInfo{}, false
+}
+
+// InputBudget returns the prompt token budget of model: its context window
+// less room for the response, with a 10% margin, rounded down to a thousand.
+// ok is false for unknown models.
+func InputBudget(model string) (budget int, ok bool) {
+	m, ok := lookupModel(model)
+	if !ok {
+		return 0, false
+	}
+	reserve := min(maxOutputReserve, m.contextWindow/4)
+	budget = int(float64(m.contextWindow-reserve) * budgetMargin)
+	return budget / 1000 * 1000, true
+}
@@ -0,0 +1,73 @@
+// Package tokenizer counts LLM prompt tokens. OpenAI models are counted
+// exactly with their BPE vocabulary (cl100k_base or o200k_base, built into
+// the binary); other models get a heuristic estimate that accounts for CJK
+// text, code and JSON.
+package tokenizer
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+// BPE encodings of OpenAI models.
+const (
+	EncodingCL100K = "cl100k_base"
+	EncodingO200K  = "o200k_base"
+)
+
+// setLoader makes tiktoken read vocabularies from the copies embedded by
+// tiktoken-go-loader instead of downloading them from OpenAI.
+var setLoader sync.Once
+
+// Tokenizer counts the tokens of a text for one model.
+type Tokenizer struct {
+	encoding string // empty for the heuristic
+	bpe      *tiktoken.Tiktoken
+}
+
+// Heuristic returns a tokenizer that estimates counts without a vocabulary.
+func Heuristic() *Tokenizer {
+	return &Tokenizer{}
+}
+
+// ForModel returns the tokenizer of model. Vocabularies are embedded and
+// never downloaded. Models without a known encoding get the heuristic. If the
+// vocabulary fails to load, the heuristic is returned with the error.
+func ForModel(model string) (*Tokenizer, error) {
+	info, ok := lookupModel(model)
+	if !ok || info.encoding == "" {
+		return Heuristic(), nil
+	}
+
+	setLoader.Do(func() { tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader()) })
+	bpe, err := tiktoken.GetEncoding(info.encoding)
+	if err != nil {
+		return Heuristic(), fmt.Errorf("failed to load %s: %w", info.encoding, err)
+	}
+	return &Tokenizer{encoding: info.encoding, bpe: bpe}, nil
+}
+
+// Count returns the number of tokens in text. Special tokens such as
+// <|endoftext|> are counted as plain text.
+func (t *Tokenizer) Count(text string) int {
+	if t == nil || t.bpe == nil {
+		return Estimate(text)
+	}
+	return len(t.bpe.EncodeOrdinary(text))
+}
+
+// Exact reports whether counts come from a BPE vocabulary.
+func (t *Tokenizer) Exact() bool {
+	return t != nil && t.bpe != nil
+}
+
+// String returns the encoding name, or "heuristic".
+func (t *Tokenizer) String() string {
+	if !t.Exact() {
+		return "heuristic"
+	}
+	return t.encoding
+}
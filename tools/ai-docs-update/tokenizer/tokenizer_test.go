@@ -0,0 +1,51 @@
+package tokenizer
+
+import "testing"
+
+func TestForModel(t *testing.T) {
+	tests := []struct {
+		model    string
+		encoding string
+		text     string
+		want     int
+	}{
+		{model: "gpt-4o", encoding: EncodingO200K, text: "hello world", want: 2},
+		{model: "gpt-4o", encoding: EncodingO200K, text: "tiktoken is great!", want: 6},
+		{model: "gpt-4o", encoding: EncodingO200K, text: "Bucketeer のフィーチャーフラグ", want: 9},
+		{model: "gpt-4", encoding: EncodingCL100K, text: "hello world", want: 2},
+		{model: "gpt-4", encoding: EncodingCL100K, text: "tiktoken is great!", want: 6},
+		{model: "gpt-4", encoding: EncodingCL100K, text: "Bucketeer のフィーチャーフラグ", want: 14},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model+"/"+tt.text, func(t *testing.T) {
+			tok, err := ForModel(tt.model)
+			if err != nil {
+				t.Fatalf("ForModel(%q) error = %v", tt.model, err)
+			}
+			if !tok.Exact() {
+				t.Fatalf("ForModel(%q).Exact() = false, want true", tt.model)
+			}
+			if got := tok.String(); got != tt.encoding {
+				t.Errorf("String() = %q, want %q", got, tt.encoding)
+			}
+			if got := tok.Count(tt.text); got != tt.want {
+				t.Errorf("Count(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForModelHeuristic(t *testing.T) {
+	for _, model := range []string{"claude-sonnet-4-5", "unknown-model", ""} {
+		tok, err := ForModel(model)
+		if err != nil {
+			t.Fatalf("ForModel(%q) error = %v", model, err)
+		}
+		if tok.Exact() {
+			t.Errorf("ForModel(%q).Exact() = true, want false", model)
+		}
+		if got, want := tok.Count("hello world"), Estimate("hello world"); got != want {
+			t.Errorf("ForModel(%q).Count() = %d, want estimate %d", model, got, want)
+		}
+	}
+}
@@ -0,0 +1,355 @@
+// Package unidiff parses unified diffs: git diff output (with renames, mode
+// changes and binary patches) and plain diff -u output.
+package unidiff
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Status is how a file changed.
+type Status string
+
+const (
+	StatusModified Status = "modified"
+	StatusAdded    Status = "added"
+	StatusDeleted  Status = "deleted"
+	StatusRenamed  Status = "renamed"
+	StatusCopied   Status = "copied"
+)
+
+// Diff is a parsed unified diff.
+type Diff struct {
+	Size  int // Size of the diff text in bytes
+	Files []File
+}
+
+// File is the diff of one file.
+type File struct {
+	OldPath    string // Path before the change; empty for added files
+	NewPath    string // Path after the change; empty for deleted files
+	Status     Status
+	Binary     bool   // Binary patch or "Binary files ... differ"; has no hunks
+	OldMode    string // File modes from git headers (e.g. "100644"), if given
+	NewMode    string
+	Similarity int // Rename or copy similarity in percent
+	Hunks      []Hunk
+	Raw        string // The file's diff text, headers included
+}
+
+// Hunk is a range of changed lines with its context.
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Section            string // Text after the closing @@ (usually the enclosing function)
+	Lines              []Line
+}
+
+// LineKind is the kind of a hunk line.
+type LineKind byte
+
+const (
+	LineContext   LineKind = ' '
+	LineAdded     LineKind = '+'
+	LineDeleted   LineKind = '-'
+	LineNoNewline LineKind = '\\' // "\ No newline at end of file" after the previous line
+)
+
+// Line is a line of a hunk.
+type Line struct {
+	Kind    LineKind
+	Text    string // Without the kind prefix
+	OldLine int    // 1-based line in the old file; 0 for added lines
+	NewLine int    // 1-based line in the new file; 0 for deleted lines
+}
+
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@ ?(.*)$`)
+
+// Parse parses a unified diff. Parsing is lenient: text before the first
+// file is ignored, lines that fit no part of the format are kept in the
+// file's Raw text but not modeled, and a hunk cut short ends at the first
+// line that cannot belong to it.
+func Parse(text string) *Diff {
+	d := &Diff{Size: len(text)}
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+
+	var cur *File
+	var raw []string
+	flush := func() {
+		if cur == nil {
+			return
+		}
+		cur.Raw = strings.Join(raw, "\n") + "\n"
+		cur.finish()
+		d.Files = append(d.Files, *cur)
+		cur, raw = nil, nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			cur = &File{}
+			cur.OldPath, cur.NewPath = parseGitPaths(strings.TrimPrefix(line, "diff --git "))
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			// Plain diff -u output has no "diff --git" line; a second ---/+++ pair starts the next file
+			if cur == nil || len(cur.Hunks) > 0 || cur.Binary {
+				flush()
+				cur = &File{}
+			}
+			cur.OldPath = parseFilePath(strings.TrimPrefix(line, "--- "), "a/")
+			cur.NewPath = parseFilePath(strings.TrimPrefix(lines[i+1], "+++ "), "b/")
+			raw = append(raw, line)
+			i++
+			line = lines[i]
+		case cur == nil:
+			continue // text before the first file
+		case strings.HasPrefix(line, "@@ "):
+			h, end, ok := parseHunk(lines, i)
+			if ok {
+				cur.Hunks = append(cur.Hunks, h)
+				raw = append(raw, lines[i:end]...)
+				i = end - 1
+				continue
+			}
+		default:
+			cur.parseHeader(line)
+		}
+		raw = append(raw, line)
+	}
+	flush()
+	return d
+}
+
+// parseHeader reads a git extended header line.
+func (f *File) parseHeader(line string) {
+	switch {
+	case strings.HasPrefix(line, "new file mode "):
+		f.Status, f.NewMode = StatusAdded, strings.TrimPrefix(line, "new file mode ")
+	case strings.HasPrefix(line, "deleted file mode "):
+		f.Status, f.OldMode = StatusDeleted, strings.TrimPrefix(line, "deleted file mode ")
+	case strings.HasPrefix(line, "old mode "):
+		f.OldMode = strings.TrimPrefix(line, "old mode ")
+	case strings.HasPrefix(line, "new mode "):
+		f.NewMode = strings.TrimPrefix(line, "new mode ")
+	case strings.HasPrefix(line, "rename from "):
+		f.Status, f.OldPath = StatusRenamed, unquote(strings.TrimPrefix(line, "rename from "))
+	case strings.HasPrefix(line, "rename to "):
+		f.Status, f.NewPath = StatusRenamed, unquote(strings.TrimPrefix(line, "rename to "))
+	case strings.HasPrefix(line, "copy from "):
+		f.Status, f.OldPath = StatusCopied, unquote(strings.TrimPrefix(line, "copy from "))
+	case strings.HasPrefix(line, "copy to "):
+		f.Status, f.NewPath = StatusCopied, unquote(strings.TrimPrefix(line, "copy to "))
+	case strings.HasPrefix(line, "similarity index "):
+		f.Similarity = atoi(strings.TrimSuffix(strings.TrimPrefix(line, "similarity index "), "%"))
+	case strings.HasPrefix(line, "Binary files ") && strings.HasSuffix(line, " differ"),
+		line == "GIT binary patch":
+		f.Binary = true
+	}
+}
+
+// finish sets the status from the paths when no header gave it.
+func (f *File) finish() {
+	switch {
+	case f.Status != "":
+	case f.OldPath == "":
+		f.Status = StatusAdded
+	case f.NewPath == "":
+		f.Status = StatusDeleted
+	default:
+		f.Status = StatusModified
+	}
+	// "new file mode" diffs still name the file on both sides of "diff --git"
+	if f.Status == StatusAdded {
+		f.OldPath = ""
+	}
+	if f.Status == StatusDeleted {
+		f.NewPath = ""
+	}
+}
+
+// parseHunk parses the hunk whose header is lines[start] and returns it with
+// the index of the first line after it.
+func parseHunk(lines []string, start int) (Hunk, int, bool) {
+	m := hunkHeaderPattern.FindStringSubmatch(lines[start])
+	if m == nil {
+		return Hunk{}, 0, false
+	}
+	h := Hunk{
+		OldStart: atoi(m[1]), OldLines: countOrOne(m[2]),
+		NewStart: atoi(m[3]), NewLines: countOrOne(m[4]),
+		Section: m[5],
+	}
+
+	oldLeft, newLeft := h.OldLines, h.NewLines
+	oldLine, newLine := h.OldStart, h.NewStart
+	i := start + 1
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, `\`) {
+			h.Lines = append(h.Lines, Line{Kind: LineNoNewline, Text: strings.TrimPrefix(line, `\ `)})
+			continue
+		}
+		if oldLeft == 0 && newLeft == 0 {
+			break
+		}
+		kind := LineContext
+		if line != "" {
+			kind = LineKind(line[0])
+		}
+		l := Line{Kind: kind}
+		if line != "" {
+			l.Text = line[1:]
+		}
+		switch {
+		case kind == LineContext && oldLeft > 0 && newLeft > 0:
+			l.OldLine, l.NewLine = oldLine, newLine
+			oldLine, newLine, oldLeft, newLeft = oldLine+1, newLine+1, oldLeft-1, newLeft-1
+		case kind == LineDeleted && oldLeft > 0:
+			l.OldLine = oldLine
+			oldLine, oldLeft = oldLine+1, oldLeft-1
+		case kind == LineAdded && newLeft > 0:
+			l.NewLine = newLine
+			newLine, newLeft = newLine+1, newLeft-1
+		default:
+			return h, i, true // cut short
+		}
+		h.Lines = append(h.Lines, l)
+	}
+	return h, i, true
+}
+
+// parseGitPaths splits the "a/old b/new" part of a "diff --git" line. Quoted
+// paths are unquoted; unquoted paths containing spaces are split in the
+// middle, which is exact for everything but renames, whose paths the rename
+// headers give anyway.
+func parseGitPaths(s string) (oldPath, newPath string) {
+	if strings.HasPrefix(s, `"`) {
+		if end := closingQuote(s); end > 0 {
+			return strings.TrimPrefix(unquote(s[:end+1]), "a/"), strings.TrimPrefix(unquote(strings.TrimSpace(s[end+1:])), "b/")
+		}
+	}
+	if strings.HasSuffix(s, `"`) {
+		if idx := strings.Index(s, ` "`); idx >= 0 {
+			return strings.TrimPrefix(s[:idx], "a/"), strings.TrimPrefix(unquote(s[idx+1:]), "b/")
+		}
+	}
+	if len(s)%2 == 1 {
+		half := len(s) / 2
+		if s[half] == ' ' && strings.TrimPrefix(s[:half], "a/") == strings.TrimPrefix(s[half+1:], "b/") {
+			return strings.TrimPrefix(s[:half], "a/"), strings.TrimPrefix(s[half+1:], "b/")
+		}
+	}
+	if idx := strings.Index(s, " b/"); idx >= 0 {
+		return strings.TrimPrefix(s[:idx], "a/"), s[idx+3:]
+	}
+	return s, s
+}
+
+// parseFilePath parses the path of a ---/+++ line: "/dev/null" gives "",
+// a diff -u timestamp after a tab is dropped, and the git prefix is removed.
+func parseFilePath(s, prefix string) string {
+	if strings.HasPrefix(s, `"`) {
+		s = unquote(s)
+	} else if tab := strings.IndexByte(s, '\t'); tab >= 0 {
+		s = s[:tab]
+	}
+	if s == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(s, prefix)
+}
+
+// closingQuote returns the index of the quote ending the quoted string at the start of s.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// unquote removes git's C-style quoting from a path, if present.
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	}
+	return s
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// countOrOne parses a hunk range length, which is 1 when omitted.
+func countOrOne(s string) int {
+	if s == "" {
+		return 1
+	}
+	return atoi(s)
+}
+
+// Path returns the file's path after the change, or before it for deletions.
+func (f *File) Path() string {
+	if f.NewPath != "" {
+		return f.NewPath
+	}
+	return f.OldPath
+}
+
+// Additions returns the number of added lines.
+func (f *File) Additions() int {
+	return f.count(LineAdded)
+}
+
+// Deletions returns the number of deleted lines.
+func (f *File) Deletions() int {
+	return f.count(LineDeleted)
+}
+
+func (f *File) count(kind LineKind) int {
+	n := 0
+	for _, h := range f.Hunks {
+		for _, l := range h.Lines {
+			if l.Kind == kind {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// Header returns the hunk's "@@ -a,b +c,d @@ section" line.
+func (h Hunk) Header() string {
+	header := "@@ -" + strconv.Itoa(h.OldStart) + "," + strconv.Itoa(h.OldLines) +
+		" +" + strconv.Itoa(h.NewStart) + "," + strconv.Itoa(h.NewLines) + " @@"
+	if h.Section != "" {
+		header += " " + h.Section
+	}
+	return header
+}
+
+// String returns the hunk in unified diff form.
+func (h Hunk) String() string {
+	var sb strings.Builder
+	sb.WriteString(h.Header())
+	sb.WriteString("\n")
+	for _, l := range h.Lines {
+		sb.WriteByte(byte(l.Kind))
+		if l.Kind == LineNoNewline {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(l.Text)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,284 @@
+package unidiff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFiles(t *testing.T) {
+	type want struct {
+		oldPath, newPath string
+		status           Status
+		binary           bool
+		oldMode, newMode string
+		similarity       int
+		hunks            int
+		additions        int
+		deletions        int
+	}
+	tests := []struct {
+		name string
+		diff string
+		want []want
+	}{
+		{
+			name: "modified with two hunks",
+			diff: `diff --git a/pkg/flag.go b/pkg/flag.go
+index 1111111..2222222 100644
+--- a/pkg/flag.go
++++ b/pkg/flag.go
+@@ -1,3 +1,4 @@ package flag
+ a
+-b
++B
++c
+ d
+@@ -10 +11 @@ func X()
+-x
++y
+`,
+			want: []want{{oldPath: "pkg/flag.go", newPath: "pkg/flag.go", status: StatusModified, hunks: 2, additions: 3, deletions: 2}},
+		},
+		{
+			name: "added and deleted",
+			diff: `diff --git a/new.md b/new.md
+new file mode 100644
+index 0000000..1111111
+--- /dev/null
++++ b/new.md
+@@ -0,0 +1,2 @@
++# New
++text
+diff --git a/old.md b/old.md
+deleted file mode 100644
+index 1111111..0000000
+--- a/old.md
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+`,
+			want: []want{
+				{newPath: "new.md", status: StatusAdded, newMode: "100644", hunks: 1, additions: 2},
+				{oldPath: "old.md", status: StatusDeleted, oldMode: "100644", hunks: 1, deletions: 1},
+			},
+		},
+		{
+			name: "pure rename and rename with changes",
+			diff: `diff --git a/docs/a.md b/docs/b.md
+similarity index 100%
+rename from docs/a.md
+rename to docs/b.md
+diff --git a/src/x.go b/src/y.go
+similarity index 92%
+rename from src/x.go
+rename to src/y.go
+index 1111111..2222222 100644
+--- a/src/x.go
++++ b/src/y.go
+@@ -1 +1 @@
+-old
++new
+`,
+			want: []want{
+				{oldPath: "docs/a.md", newPath: "docs/b.md", status: StatusRenamed, similarity: 100},
+				{oldPath: "src/x.go", newPath: "src/y.go", status: StatusRenamed, similarity: 92, hunks: 1, additions: 1, deletions: 1},
+			},
+		},
+		{
+			name: "copy",
+			diff: `diff --git a/a.go b/b.go
+similarity index 88%
+copy from a.go
+copy to b.go
+`,
+			want: []want{{oldPath: "a.go", newPath: "b.go", status: StatusCopied, similarity: 88}},
+		},
+		{
+			name: "mode change",
+			diff: `diff --git a/run.sh b/run.sh
+old mode 100644
+new mode 100755
+`,
+			want: []want{{oldPath: "run.sh", newPath: "run.sh", status: StatusModified, oldMode: "100644", newMode: "100755"}},
+		},
+		{
+			name: "binary files",
+			diff: `diff --git a/static/img/a.png b/static/img/a.png
+index 1111111..2222222 100644
+Binary files a/static/img/a.png and b/static/img/a.png differ
+diff --git a/static/img/b.png b/static/img/b.png
+new file mode 100644
+index 0000000..1111111
+GIT binary patch
+literal 5
+McmZQzWMXCn00I0RR91
+`,
+			want: []want{
+				{oldPath: "static/img/a.png", newPath: "static/img/a.png", status: StatusModified, binary: true},
+				{newPath: "static/img/b.png", status: StatusAdded, binary: true, newMode: "100644"},
+			},
+		},
+		{
+			name: "paths with spaces and quotes",
+			diff: `diff --git a/docs/my doc.md b/docs/my doc.md
+--- a/docs/my doc.md
++++ b/docs/my doc.md
+@@ -1 +1 @@
+-a
++b
+diff --git "a/docs/\343\203\225.md" "b/docs/\343\203\225.md"
+--- "a/docs/\343\203\225.md"
++++ "b/docs/\343\203\225.md"
+@@ -1 +1 @@
+-a
++b
+`,
+			want: []want{
+				{oldPath: "docs/my doc.md", newPath: "docs/my doc.md", status: StatusModified, hunks: 1, additions: 1, deletions: 1},
+				{oldPath: "docs/フ.md", newPath: "docs/フ.md", status: StatusModified, hunks: 1, additions: 1, deletions: 1},
+			},
+		},
+		{
+			name: "plain diff -u with timestamps",
+			diff: `Only in a: tmp
+--- a/one.txt	2026-01-01 00:00:00.000000000 +0000
++++ b/one.txt	2026-01-02 00:00:00.000000000 +0000
+@@ -1,2 +1,2 @@
+ same
+-old
++new
+--- a/two.txt	2026-01-01 00:00:00.000000000 +0000
++++ b/two.txt	2026-01-02 00:00:00.000000000 +0000
+@@ -1 +1,2 @@
+ keep
++added
+`,
+			want: []want{
+				{oldPath: "one.txt", newPath: "one.txt", status: StatusModified, hunks: 1, additions: 1, deletions: 1},
+				{oldPath: "two.txt", newPath: "two.txt", status: StatusModified, hunks: 1, additions: 1},
+			},
+		},
+		{
+			name: "hunk cut short",
+			diff: `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,5 +1,5 @@
+ one
+-two
++TWO
+diff --git a/b.go b/b.go
+--- a/b.go
++++ b/b.go
+@@ -1 +1 @@
+-x
++y
+`,
+			want: []want{
+				{oldPath: "a.go", newPath: "a.go", status: StatusModified, hunks: 1, additions: 1, deletions: 1},
+				{oldPath: "b.go", newPath: "b.go", status: StatusModified, hunks: 1, additions: 1, deletions: 1},
+			},
+		},
+		{
+			name: "not a diff",
+			diff: "just some text\n--- not a header\n",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Parse(tt.diff)
+			if d.Size != len(tt.diff) {
+				t.Errorf("Size = %d, want %d", d.Size, len(tt.diff))
+			}
+			if len(d.Files) != len(tt.want) {
+				t.Fatalf("got %d files, want %d: %+v", len(d.Files), len(tt.want), d.Files)
+			}
+			for i, f := range d.Files {
+				got := want{
+					oldPath: f.OldPath, newPath: f.NewPath, status: f.Status, binary: f.Binary,
+					oldMode: f.OldMode, newMode: f.NewMode, similarity: f.Similarity,
+					hunks: len(f.Hunks), additions: f.Additions(), deletions: f.Deletions(),
+				}
+				if got != tt.want[i] {
+					t.Errorf("file %d = %+v, want %+v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseRaw(t *testing.T) {
+	diff := `preamble
+diff --git a/a.md b/a.md
+index 1111111..2222222 100644
+--- a/a.md
++++ b/a.md
+@@ -1,2 +1,2 @@
+ a
+-b
++c
+\ No newline at end of file
+diff --git a/b.md b/b.md
+similarity index 100%
+rename from b.md
+rename to c.md
+`
+	d := Parse(diff)
+	var sb strings.Builder
+	for _, f := range d.Files {
+		sb.WriteString(f.Raw)
+	}
+	if want := strings.TrimPrefix(diff, "preamble\n"); sb.String() != want {
+		t.Errorf("concatenated Raw =\n%s\nwant\n%s", sb.String(), want)
+	}
+	if got := d.Files[1].Path(); got != "c.md" {
+		t.Errorf("Path() of the rename = %q, want c.md", got)
+	}
+}
+
+func TestParseHunkLines(t *testing.T) {
+	diff := `--- a/f.txt
++++ b/f.txt
+@@ -3,4 +3,4 @@ func main() {
+ three
+-four
++FOUR
+ five
+-six
+\ No newline at end of file
++six
+`
+	f := Parse(diff).Files[0]
+	h := f.Hunks[0]
+	if h.OldStart != 3 || h.OldLines != 4 || h.NewStart != 3 || h.NewLines != 4 || h.Section != "func main() {" {
+		t.Errorf("hunk range = %+v", h)
+	}
+	want := []Line{
+		{Kind: LineContext, Text: "three", OldLine: 3, NewLine: 3},
+		{Kind: LineDeleted, Text: "four", OldLine: 4},
+		{Kind: LineAdded, Text: "FOUR", NewLine: 4},
+		{Kind: LineContext, Text: "five", OldLine: 5, NewLine: 5},
+		{Kind: LineDeleted, Text: "six", OldLine: 6},
+		{Kind: LineNoNewline, Text: "No newline at end of file"},
+		{Kind: LineAdded, Text: "six", NewLine: 6},
+	}
+	if len(h.Lines) != len(want) {
+		t.Fatalf("got %d lines, want %d: %+v", len(h.Lines), len(want), h.Lines)
+	}
+	for i, l := range h.Lines {
+		if l != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, l, want[i])
+		}
+	}
+	if got, want := h.String(), strings.SplitN(diff, "\n", 3)[2]; got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHunkHeader(t *testing.T) {
+	h := Parse("--- a/f\n+++ b/f\n@@ -1 +1 @@\n-a\n+b\n").Files[0].Hunks[0]
+	if got, want := h.Header(), "@@ -1,1 +1,1 @@"; got != want {
+		t.Errorf("Header() = %q, want %q", got, want)
+	}
+}